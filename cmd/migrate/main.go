@@ -0,0 +1,142 @@
+// Comando migrate: aplica, reverte e lista as migrações do banco configurado em
+// cmd/server/.env, e cria os arquivos de uma migração nova.
+//
+//	go run ./cmd/migrate up [versão]
+//	go run ./cmd/migrate down [quantidade]
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate create <nome>
+package main
+
+import (
+	"apis/configs"
+	"apis/db"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const usage = `uso: migrate <comando>
+
+  up [versão]         aplica as migrações pendentes (até a versão, se informada)
+  down [quantidade]   reverte as últimas migrações aplicadas (padrão 1)
+  status              lista as migrações e o estado de cada uma
+  create <nome>       cria os arquivos .up.sql e .down.sql da próxima migração
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	command, args := args[0], args[1:]
+
+	// create não precisa de banco
+	if command == "create" {
+		if len(args) != 1 {
+			return fmt.Errorf("%s", usage)
+		}
+		files, err := db.Create(db.MigrationsDir, args[0])
+		for _, file := range files {
+			fmt.Println("criado", file)
+		}
+		return err
+	}
+
+	number := 0
+	switch command {
+	case "up", "down":
+		if len(args) > 1 {
+			return fmt.Errorf("%s", usage)
+		}
+		if command == "down" {
+			number = 1
+		}
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("número inválido: %s", args[0])
+			}
+			number = n
+		}
+	case "status":
+		if len(args) != 0 {
+			return fmt.Errorf("%s", usage)
+		}
+	default:
+		return fmt.Errorf("%s", usage)
+	}
+
+	cfg, err := configs.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("erro ao carregar as configs: %w", err)
+	}
+	gormDB, err := db.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := db.NewMigrator(gormDB, db.Options{DefaultCurrency: cfg.DefaultCurrency})
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(number)
+		for _, migration := range applied {
+			fmt.Printf("aplicada %04d %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("nenhuma migração pendente")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(number)
+		for _, migration := range reverted {
+			fmt.Printf("revertida %04d %s\n", migration.Version, migration.Name)
+		}
+		return err
+	default:
+		return printStatus(migrator)
+	}
+}
+
+func printStatus(migrator *db.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSÃO\tNOME\tESTADO\tAPLICADA EM")
+	for _, status := range statuses {
+		state := "pendente"
+		switch {
+		case status.Dirty:
+			state = "dirty"
+		case status.Unknown:
+			state = "desconhecida"
+		case status.Modified:
+			state = "alterada"
+		case status.Applied:
+			state = "aplicada"
+		}
+		appliedAt := "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
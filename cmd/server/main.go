@@ -4,18 +4,24 @@ import (
 	"apis/configs"
 	"apis/db"
 	"apis/internal/entity"
+	"apis/internal/infra/blob"
 	"apis/internal/infra/database"
+	"apis/internal/infra/exchange"
 	"apis/internal/infra/webserver/handlers"
+	authmiddleware "apis/internal/middleware"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	_ "apis/docs"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -41,82 +47,309 @@ func main() {
 	if err != nil {
 		panic(fmt.Sprintf("erro ao carregar as configs: %v", err))
 	}
+	if !entity.Role(cfg.DefaultUserRole).IsValid() {
+		panic(fmt.Sprintf("DEFAULT_USER_ROLE inválido: %s", cfg.DefaultUserRole))
+	}
+	// Qualquer pessoa pode se cadastrar, então o cadastro nunca dá acesso de admin
+	if entity.Role(cfg.DefaultUserRole) == entity.RoleAdmin {
+		panic("DEFAULT_USER_ROLE não pode ser admin; promova o primeiro admin com go run ./cmd/users role <email> admin")
+	}
+	if !money.IsValidCurrency(cfg.DefaultCurrency) {
+		panic(fmt.Sprintf("DEFAULT_CURRENCY inválido: %s", cfg.DefaultCurrency))
+	}
 
-	sqlDB, err := db.Connect()
+	// O driver (sqlite, postgres ou mysql) vem de DB_DSN
+	gormDB, err := db.Connect(cfg)
 	if err != nil {
-		panic("Erro ao conectar ao banco")
+		panic(fmt.Sprintf("Erro ao conectar ao banco: %v", err))
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		panic(fmt.Sprintf("Erro ao conectar ao banco: %v", err))
 	}
 	// Close está embutido no *sql.DB
 	defer sqlDB.Close()
 
-	// Conecta o GORM usando a conexão existente
-	gormDB, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{})
+	// O esquema é aplicado pelo comando migrate; o servidor só confere se está em dia
+	migrator, err := db.NewMigrator(gormDB, db.Options{DefaultCurrency: cfg.DefaultCurrency})
 	if err != nil {
-		panic(fmt.Sprintf("erro ao conectar o GORM: %v", err))
+		panic(fmt.Sprintf("erro ao carregar as migrações: %v", err))
+	}
+	if err := migrator.Check(); err != nil {
+		panic(fmt.Sprintf("esquema do banco desatualizado: %v (rode go run ./cmd/migrate up)", err))
 	}
 
-	// Migração
-	if err := gormDB.AutoMigrate(&entity.Product{}, &entity.User{}); err != nil {
-		panic(fmt.Sprintf("erro ao migrar: %v", err))
+	rates, err := newExchangeRateProvider(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("erro ao carregar as cotações: %v", err))
+	}
+
+	store, err := newBlobStore(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("erro ao abrir o armazenamento de arquivos: %v", err))
 	}
 
 	// Handlers
-	productHandler, userHandler := setupHandlers(gormDB, cfg)
+	tokenDB := database.NewToken(gormDB)
+	productHandler, categoryHandler, tagHandler, variantHandler, stockHandler, imageHandler, userHandler, orderHandler, auditHandler := setupHandlers(gormDB, tokenDB, cfg, rates, store)
+
+	// Limpeza automática da lixeira de produtos
+	startTrashPurger(productHandler.Transactor, cfg.TrashRetentionDays)
+
+	// Liberação das reservas de estoque vencidas
+	startReservationSweeper(stockHandler.StockDB)
+
+	// Remoção dos arquivos das imagens de produtos removidos definitivamente
+	startImageSweeper(imageHandler)
 
 	// Rotas
-	r := setupRouter(cfg, productHandler, userHandler)
+	r := setupRouter(cfg, tokenDB, productHandler, categoryHandler, tagHandler, variantHandler, stockHandler, imageHandler, userHandler, orderHandler, auditHandler)
 
 	fmt.Println("Servidor iniciado em :8080")
 	http.ListenAndServe(":8080", r)
 }
 
 // inicializa os handlers com o banco de dados
-func setupHandlers(db *gorm.DB, cfg *configs.Conf) (*handlers.ProductHandler, *handlers.UserHandler) {
+func setupHandlers(db *gorm.DB, tokenDB database.TokenInterface, cfg *configs.Conf, rates exchange.ExchangeRateProvider, store blob.BlobStore) (*handlers.ProductHandler, *handlers.CategoryHandler, *handlers.TagHandler, *handlers.VariantHandler, *handlers.StockHandler, *handlers.ProductImageHandler, *handlers.UserHandler, *handlers.OrderHandler, *handlers.AuditHandler) {
 	productDB := database.NewProduct(db)
+	categoryDB := database.NewCategory(db)
+	tagDB := database.NewTag(db)
+	variantDB := database.NewVariant(db)
+	stockDB := database.NewStock(db)
+	imageDB := database.NewProductImage(db)
 	userDB := database.NewUser(db)
-	// orderRepo := database.NewOrder(gormDB)  // camada de acesso ao banco
+	orderDB := database.NewOrder(db) // camada de acesso ao banco
+	auditDB := database.NewAudit(db)
+	transactor := database.NewTransactor(db) // alterações que gravam a auditoria na mesma transação
 
-	productHandler := handlers.NewProductHandler(productDB)
-	userHandler := handlers.NewUserHandler(userDB, cfg.TokenAuth, cfg.JwtExpiresIn)
-	// orderHandler := handlers.NewOrderHandler(orderRepo)  // camada web
+	productHandler := handlers.NewProductHandler(productDB, categoryDB, tagDB, variantDB, transactor, cfg.DefaultCurrency, rates, cfg.MaxImportSize)
+	categoryHandler := handlers.NewCategoryHandler(categoryDB, transactor)
+	tagHandler := handlers.NewTagHandler(tagDB, transactor)
+	variantHandler := handlers.NewVariantHandler(variantDB, productDB, transactor)
+	stockHandler := handlers.NewStockHandler(stockDB, productDB, variantDB, transactor)
+	imageHandler := handlers.NewProductImageHandler(imageDB, productDB, transactor, store, cfg.MaxImageSize)
+	userHandler := handlers.NewUserHandler(userDB, tokenDB, transactor, cfg.TokenAuth, cfg.JwtExpiresIn, cfg.RefreshExpiresIn, entity.Role(cfg.DefaultUserRole))
+	orderHandler := handlers.NewOrderHandler(orderDB, productDB) // camada web
+	auditHandler := handlers.NewAuditHandler(auditDB, productDB)
 
-	return productHandler, userHandler
+	return productHandler, categoryHandler, tagHandler, variantHandler, stockHandler, imageHandler, userHandler, orderHandler, auditHandler
+}
+
+// escolhe a fonte de cotações: o arquivo, se configurado, ou a tabela fixa do .env
+func newExchangeRateProvider(cfg *configs.Conf) (exchange.ExchangeRateProvider, error) {
+	if cfg.ExchangeRatesFile != "" {
+		return exchange.NewFileProvider(cfg.ExchangeRatesFile)
+	}
+	rates, err := exchange.ParseRates(cfg.ExchangeRates)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.NewStaticProvider(cfg.DefaultCurrency, rates)
+}
+
+// escolhe onde os arquivos enviados (imagens de produtos) são guardados
+func newBlobStore(cfg *configs.Conf) (blob.BlobStore, error) {
+	switch cfg.BlobStore {
+	case "filesystem":
+		return blob.NewFileStore(cfg.BlobDir)
+	case "memory":
+		return blob.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("BLOB_STORE inválido: %q (use filesystem ou memory)", cfg.BlobStore)
+	}
 }
 
 // configura as rotas do servidor
-func setupRouter(cfg *configs.Conf, productHandler *handlers.ProductHandler, userHandler *handlers.UserHandler) http.Handler {
+func setupRouter(cfg *configs.Conf, tokenDB database.TokenInterface, productHandler *handlers.ProductHandler, categoryHandler *handlers.CategoryHandler, tagHandler *handlers.TagHandler, variantHandler *handlers.VariantHandler, stockHandler *handlers.StockHandler, imageHandler *handlers.ProductImageHandler, userHandler *handlers.UserHandler, orderHandler *handlers.OrderHandler, auditHandler *handlers.AuditHandler) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	// recovers from panics, logs the panic (and a backtrace), and returns a HTTP 500 (Internal Server Error) status if possible
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.WithValue("jwt", cfg.TokenAuth))
-	r.Use(middleware.WithValue("jwtExpiresIn", cfg.JwtExpiresIn))
 
 	// Rotas públicas
 	r.Post("/users", userHandler.Create)
 	r.Post("/users/login", userHandler.GenerateJWT)
+	r.Post("/users/refresh", userHandler.Refresh)
+	// as imagens são públicas para poderem ser usadas direto em <img>
+	r.Get("/products/{id}/images/{imageID}", imageHandler.Serve)
+	r.Get("/products/{id}/images/{imageID}/thumbnail", imageHandler.ServeThumbnail)
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
 	))
 
 	// Rotas protegidas
-	registerProtectedRoutes(r, cfg, productHandler)
+	registerProtectedRoutes(r, cfg, tokenDB, productHandler, categoryHandler, tagHandler, variantHandler, stockHandler, imageHandler, userHandler, orderHandler, auditHandler)
 
 	return r
 }
 
 // registra as rotas protegidas
-func registerProtectedRoutes(r chi.Router, cfg *configs.Conf, productHandler *handlers.ProductHandler) {
+func registerProtectedRoutes(r chi.Router, cfg *configs.Conf, tokenDB database.TokenInterface, productHandler *handlers.ProductHandler, categoryHandler *handlers.CategoryHandler, tagHandler *handlers.TagHandler, variantHandler *handlers.VariantHandler, stockHandler *handlers.StockHandler, imageHandler *handlers.ProductImageHandler, userHandler *handlers.UserHandler, orderHandler *handlers.OrderHandler, auditHandler *handlers.AuditHandler) {
 	r.Group(func(r chi.Router) {
 		r.Use(jwtauth.Verifier(cfg.TokenAuth))
 		r.Use(jwtauth.Authenticator(cfg.TokenAuth))
+		r.Use(authmiddleware.RejectRevokedTokens(tokenDB))
+
+		r.Post("/users/logout", userHandler.Logout)
+		r.Route("/users/me", func(r chi.Router) {
+			r.Get("/", userHandler.GetMe)
+			r.Patch("/", userHandler.UpdateMe)
+			r.Delete("/", userHandler.DeleteMe)
+			r.Put("/password", userHandler.ChangePassword)
+		})
+
+		// viewers só leem; editors e admins podem escrever
+		canWrite := authmiddleware.RequireRole(entity.RoleAdmin, entity.RoleEditor)
+		adminOnly := authmiddleware.RequireRole(entity.RoleAdmin)
+
+		r.With(adminOnly).Put("/users/{id}/role", userHandler.SetRole)
 
 		r.Route("/products", func(r chi.Router) {
-			r.Post("/", productHandler.Create)
+			r.With(canWrite).Post("/", productHandler.Create)
 			r.Get("/", productHandler.GetAll)
+			r.With(canWrite).Post("/import", productHandler.Import)
+			r.With(canWrite).Post("/batch", productHandler.Batch)
+			r.Get("/export", productHandler.Export)
+			r.With(adminOnly).Get("/trash", productHandler.Trash)
 			r.Get("/{id}", productHandler.GetByID)
-			r.Put("/{id}", productHandler.Update)
-			r.Delete("/{id}", productHandler.Delete)
+			r.With(canWrite).Put("/{id}", productHandler.Update)
+			r.With(canWrite).Patch("/{id}", productHandler.Patch)
+			r.With(canWrite).Delete("/{id}", productHandler.Delete)
+			r.With(adminOnly).Post("/{id}/restore", productHandler.Restore)
+			r.With(adminOnly).Delete("/{id}/purge", productHandler.Purge)
+			r.Get("/{id}/history", auditHandler.History)
+			r.Get("/{id}/revisions", productHandler.Revisions)
+			r.With(canWrite).Post("/{id}/revert", productHandler.Revert)
+			r.With(canWrite).Put("/{id}/prices/{currency}", productHandler.SetPrice)
+			r.With(canWrite).Delete("/{id}/prices/{currency}", productHandler.DeletePrice)
+			r.With(canWrite).Post("/{id}/variants", variantHandler.Create)
+			r.Get("/{id}/variants", variantHandler.GetAll)
+			r.Get("/{id}/variants/{variantID}", variantHandler.GetByID)
+			r.With(canWrite).Put("/{id}/variants/{variantID}", variantHandler.Update)
+			r.With(canWrite).Delete("/{id}/variants/{variantID}", variantHandler.Delete)
+			r.With(canWrite).Post("/{id}/images", imageHandler.Upload)
+			r.Get("/{id}/images", imageHandler.GetAll)
+			r.With(canWrite).Delete("/{id}/images/{imageID}", imageHandler.Delete)
+			// o estoque do produto e o de cada variante usam as mesmas rotas
+			for _, stock := range []string{"/{id}/stock", "/{id}/variants/{variantID}/stock"} {
+				r.Get(stock, stockHandler.GetStock)
+				r.Get(stock+"/movements", stockHandler.Movements)
+				r.With(canWrite).Post(stock+"/adjustments", stockHandler.Adjust)
+				r.With(canWrite).Post(stock+"/reservations", stockHandler.Reserve)
+				r.With(canWrite).Delete(stock+"/reservations/{reservationID}", stockHandler.Release)
+				r.With(canWrite).Post(stock+"/reservations/{reservationID}/commit", stockHandler.Commit)
+			}
+		})
+
+		r.Route("/categories", func(r chi.Router) {
+			r.With(canWrite).Post("/", categoryHandler.Create)
+			r.Get("/", categoryHandler.GetAll)
+			r.Get("/{id}", categoryHandler.GetByID)
+			r.With(canWrite).Put("/{id}", categoryHandler.Update)
+			r.With(canWrite).Delete("/{id}", categoryHandler.Delete)
+			r.Get("/{id}/products", productHandler.ByCategory)
+		})
+
+		r.Route("/tags", func(r chi.Router) {
+			r.With(canWrite).Post("/", tagHandler.Create)
+			r.Get("/", tagHandler.GetAll)
+			r.Get("/{id}", tagHandler.GetByID)
+			r.With(canWrite).Put("/{id}", tagHandler.Update)
+			r.With(canWrite).Delete("/{id}", tagHandler.Delete)
+		})
+
+		r.With(adminOnly).Get("/audit", auditHandler.GetAll)
+
+		r.Route("/orders", func(r chi.Router) {
+			r.Post("/", orderHandler.Create)
+			r.Get("/", orderHandler.GetAll)
+			r.Get("/{id}", orderHandler.GetByID)
+			r.Post("/{id}/cancel", orderHandler.Cancel)
 		})
 	})
 }
+
+// remove periodicamente os produtos que estão na lixeira há mais de retentionDays dias;
+// no log de auditoria a remoção aparece sem autor, gravada na mesma transação
+func startTrashPurger(transactor database.TransactorInterface, retentionDays int) {
+	if retentionDays <= 0 {
+		return
+	}
+	purge := func() {
+		cutoff := time.Now().AddDate(0, 0, -retentionDays)
+		var purged []string
+		err := transactor.Transaction(func(tx *database.Repositories) error {
+			var err error
+			purged, err = tx.Products.PurgeDeletedBefore(cutoff)
+			if err != nil {
+				return err
+			}
+			entries := make([]*entity.AuditEntry, len(purged))
+			for i, id := range purged {
+				productID, err := entitypkg.ParseID(id)
+				if err != nil {
+					return err
+				}
+				entries[i], err = entity.NewAuditEntry(nil, entity.AuditPurge, entity.AuditProduct, productID, nil, nil)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Audit.Record(entries...)
+		})
+		if err != nil {
+			log.Printf("erro ao limpar a lixeira: %v", err)
+			return
+		}
+		if len(purged) > 0 {
+			log.Printf("%d produtos removidos da lixeira", len(purged))
+		}
+	}
+	go func() {
+		purge()
+		for range time.Tick(time.Hour) {
+			purge()
+		}
+	}()
+}
+
+// libera periodicamente as reservas de estoque vencidas; leituras e novas reservas
+// também liberam as do produto, então isto só evita que reservas esquecidas fiquem ativas
+func startReservationSweeper(stockDB database.StockInterface) {
+	sweep := func() {
+		released, err := stockDB.ReleaseExpired(time.Now())
+		if err != nil {
+			log.Printf("erro ao liberar reservas vencidas: %v", err)
+			return
+		}
+		if released > 0 {
+			log.Printf("%d reservas de estoque vencidas liberadas", released)
+		}
+	}
+	go func() {
+		sweep()
+		for range time.Tick(time.Minute) {
+			sweep()
+		}
+	}()
+}
+
+// apaga periodicamente os arquivos e os registros das imagens de produtos removidos
+// definitivamente (pela lixeira ou pelo purge), já que o banco não apaga arquivos
+func startImageSweeper(imageHandler *handlers.ProductImageHandler) {
+	sweep := func() {
+		deleted, err := imageHandler.DeleteOrphans()
+		if err != nil {
+			log.Printf("erro ao remover imagens órfãs: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("%d imagens de produtos removidos apagadas", deleted)
+		}
+	}
+	go func() {
+		sweep()
+		for range time.Tick(time.Hour) {
+			sweep()
+		}
+	}()
+}
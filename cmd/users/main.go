@@ -0,0 +1,90 @@
+// Comando users: tarefas de administração de usuários direto no banco configurado em
+// cmd/server/.env. Serve para promover o primeiro admin, já que pela API só um admin
+// altera papéis e o cadastro nunca cria admins.
+//
+//	go run ./cmd/users role <email> <papel>
+package main
+
+import (
+	"apis/configs"
+	"apis/db"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"errors"
+	"fmt"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+const usage = `uso: users <comando>
+
+  role <email> <papel>   define o papel (viewer, editor ou admin) do usuário
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) != 3 || args[0] != "role" {
+		return fmt.Errorf("%s", usage)
+	}
+	email, role := args[1], entity.Role(args[2])
+	if !role.IsValid() {
+		return fmt.Errorf("papel inválido: %s", role)
+	}
+
+	cfg, err := configs.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("erro ao carregar as configs: %w", err)
+	}
+	gormDB, err := db.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := db.NewMigrator(gormDB, db.Options{DefaultCurrency: cfg.DefaultCurrency})
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("esquema do banco desatualizado: %w (rode go run ./cmd/migrate up)", err)
+	}
+
+	err = database.NewTransactor(gormDB).Transaction(func(tx *database.Repositories) error {
+		user, err := tx.Users.GetByEmail(email)
+		if err != nil {
+			return err
+		}
+		before := user.AuditFields()
+		if err := user.SetRole(role); err != nil {
+			return err
+		}
+		if err := tx.Users.Update(user); err != nil {
+			return err
+		}
+		// Feito fora da API, sem autor, como a limpeza automática da lixeira
+		entry, err := entity.NewAuditEntry(nil, entity.AuditUpdate, entity.AuditUser, user.ID, before, user.AuditFields())
+		if err != nil {
+			return err
+		}
+		return tx.Audit.Record(entry)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("usuário não encontrado: %s", email)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("papel de %s alterado para %s\n", email, role)
+	return nil
+}
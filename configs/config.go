@@ -5,19 +5,43 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/joho/godotenv"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Drivers de banco aceitos em DB_DSN
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
 // Conf representa as configurações do banco
 type Conf struct {
-	DBFile       string
-	DBMode       string
-	DBTimeout    string
-	TokenAuth    *jwtauth.JWTAuth
-	JwtExpiresIn int
+	DBFile             string
+	DBMode             string
+	DBTimeout          string
+	DBDriver           string        // sqlite, postgres ou mysql, deduzido de DB_DSN
+	DBDSN              string        // DSN no formato do driver, sem o prefixo do esquema
+	DBMaxOpenConns     int           // 0 não limita as conexões abertas
+	DBMaxIdleConns     int           // conexões mantidas ociosas no pool
+	DBConnMaxLifetime  time.Duration // 0 mantém as conexões indefinidamente
+	TokenAuth          *jwtauth.JWTAuth
+	JwtExpiresIn       int
+	RefreshExpiresIn   int
+	DefaultUserRole    string
+	TrashRetentionDays int
+	DefaultCurrency    string
+	ExchangeRates      string // cotações fixas em relação a DefaultCurrency, como "USD=0.18,EUR=0.16"
+	ExchangeRatesFile  string // arquivo JSON com as cotações; tem precedência sobre ExchangeRates
+	BlobStore          string // onde ficam as imagens: "filesystem" ou "memory"
+	BlobDir            string // pasta das imagens com BlobStore=filesystem
+	MaxImageSize       int64  // tamanho máximo de uma imagem enviada, em bytes
+	MaxImportSize      int64  // tamanho máximo do corpo de uma importação de produtos, em bytes
 }
 
 // LoadConfig carrega as configurações do .env e retorna uma instância de Conf
@@ -36,13 +60,133 @@ func LoadConfig() (*Conf, error) {
 		log.Fatalf("JWT_EXPIRATION inválido: %v", err)
 	}
 
+	refreshString := os.Getenv("REFRESH_TOKEN_EXPIRATION")
+	if refreshString == "" {
+		refreshString = "604800" // valor padrão de 7 dias
+	}
+	refreshInt, err := strconv.Atoi(refreshString)
+	if err != nil {
+		log.Fatalf("REFRESH_TOKEN_EXPIRATION inválido: %v", err)
+	}
+
+	retentionString := os.Getenv("TRASH_RETENTION_DAYS")
+	if retentionString == "" {
+		retentionString = "30" // valor padrão de 30 dias; 0 desativa a limpeza automática
+	}
+	retentionInt, err := strconv.Atoi(retentionString)
+	if err != nil {
+		log.Fatalf("TRASH_RETENTION_DAYS inválido: %v", err)
+	}
+
+	defaultRole := os.Getenv("DEFAULT_USER_ROLE")
+	if defaultRole == "" {
+		defaultRole = "viewer" // novos usuários só podem ler por padrão
+	}
+
+	defaultCurrency := os.Getenv("DEFAULT_CURRENCY")
+	if defaultCurrency == "" {
+		defaultCurrency = "BRL" // moeda dos preços enviados sem currency
+	}
+
+	blobStore := os.Getenv("BLOB_STORE")
+	if blobStore == "" {
+		blobStore = "filesystem"
+	}
+
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "uploads"
+	}
+
+	maxImageString := os.Getenv("MAX_IMAGE_SIZE")
+	if maxImageString == "" {
+		maxImageString = "5242880" // valor padrão de 5 MiB
+	}
+	maxImageSize, err := strconv.ParseInt(maxImageString, 10, 64)
+	if err != nil || maxImageSize <= 0 {
+		log.Fatalf("MAX_IMAGE_SIZE inválido: %s", maxImageString)
+	}
+
+	maxImportString := os.Getenv("MAX_IMPORT_SIZE")
+	if maxImportString == "" {
+		maxImportString = "10485760" // valor padrão de 10 MiB
+	}
+	maxImportSize, err := strconv.ParseInt(maxImportString, 10, 64)
+	if err != nil || maxImportSize <= 0 {
+		log.Fatalf("MAX_IMPORT_SIZE inválido: %s", maxImportString)
+	}
+
+	// Sem DB_DSN o banco é o SQLite de DB_FILE
+	dbDriver, dbDSN := DriverSQLite, os.Getenv("DB_FILE")
+	if raw := os.Getenv("DB_DSN"); raw != "" {
+		if dbDriver, dbDSN, err = ParseDSN(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	maxOpenConns, err := intEnv("DB_MAX_OPEN_CONNS", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxIdleConns, err := intEnv("DB_MAX_IDLE_CONNS", 2) // mesmo padrão do database/sql
+	if err != nil {
+		return nil, err
+	}
+	connMaxLifetime, err := intEnv("DB_CONN_MAX_LIFETIME", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Conf{
-		DBFile:       os.Getenv("DB_FILE"),
-		DBMode:       os.Getenv("DB_MODE"),
-		DBTimeout:    os.Getenv("DB_TIMEOUT"),
-		TokenAuth:    jwtauth.New("HS256", []byte(os.Getenv("JWT_SECRET")), nil),
-		JwtExpiresIn: expInt, // Tempo de expiração do token em segundos
+		DBFile:             os.Getenv("DB_FILE"),
+		DBMode:             os.Getenv("DB_MODE"),
+		DBTimeout:          os.Getenv("DB_TIMEOUT"),
+		DBDriver:           dbDriver,
+		DBDSN:              dbDSN,
+		DBMaxOpenConns:     maxOpenConns,
+		DBMaxIdleConns:     maxIdleConns,
+		DBConnMaxLifetime:  time.Duration(connMaxLifetime) * time.Second,
+		TokenAuth:          jwtauth.New("HS256", []byte(os.Getenv("JWT_SECRET")), nil),
+		JwtExpiresIn:       expInt,     // Tempo de expiração do token em segundos
+		RefreshExpiresIn:   refreshInt, // Tempo de expiração do refresh token em segundos
+		DefaultUserRole:    defaultRole,
+		TrashRetentionDays: retentionInt, // Dias que um produto fica na lixeira antes de ser removido
+		DefaultCurrency:    defaultCurrency,
+		ExchangeRates:      os.Getenv("EXCHANGE_RATES"),
+		ExchangeRatesFile:  os.Getenv("EXCHANGE_RATES_FILE"),
+		BlobStore:          blobStore,
+		BlobDir:            blobDir,
+		MaxImageSize:       maxImageSize,
+		MaxImportSize:      maxImportSize,
 	}
 
 	return config, nil
 }
+
+// ParseDSN separa o driver do DSN pelo esquema: postgres:// (ou postgresql://) vai inteiro
+// para o driver do Postgres; em mysql:// e sqlite:// o esquema é removido e o restante
+// segue o formato do respectivo driver, como "user:pass@tcp(localhost:3306)/apis".
+func ParseDSN(raw string) (driver, dsn string, err error) {
+	switch {
+	case strings.HasPrefix(raw, "postgres://"), strings.HasPrefix(raw, "postgresql://"):
+		return DriverPostgres, raw, nil
+	case strings.HasPrefix(raw, "mysql://"):
+		return DriverMySQL, strings.TrimPrefix(raw, "mysql://"), nil
+	case strings.HasPrefix(raw, "sqlite://"):
+		return DriverSQLite, strings.TrimPrefix(raw, "sqlite://"), nil
+	}
+	return "", "", fmt.Errorf("DB_DSN inválido: use postgres://, mysql:// ou sqlite://")
+}
+
+// intEnv lê um inteiro não negativo do ambiente, com valor padrão quando a variável está vazia
+func intEnv(key string, fallback int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s inválido: %s", key, raw)
+	}
+	return value, nil
+}
@@ -2,26 +2,87 @@ package db
 
 import (
 	"apis/configs"
-	"database/sql"
 	"fmt"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
-func Connect() (*sql.DB, error) {
-	config, err := configs.LoadConfig()
+// Connect abre o banco configurado em DB_DSN (ou o SQLite de DB_FILE) e aplica as
+// configurações do pool de conexões
+func Connect(config *configs.Conf) (*gorm.DB, error) {
+	dsn := config.DBDSN
+	if config.DBDriver == configs.DriverSQLite {
+		dsn = SQLiteDSN(dsn, config.DBTimeout)
+	}
+	dialector, err := Dialector(config.DBDriver, dsn)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao carregar configuração: %w", err)
+		return nil, err
 	}
 
-	db, err := sql.Open("sqlite3", config.DBFile)
+	// TranslateError converte as violações de índice único de cada driver em gorm.ErrDuplicatedKey
+	gormDB, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir conexão com o banco: %w", err)
+	}
+	sqlDB, err := gormDB.DB()
 	if err != nil {
 		return nil, fmt.Errorf("erro ao abrir conexão com o banco: %w", err)
 	}
+	sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.DBConnMaxLifetime)
 
-	if err := db.Ping(); err != nil {
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
 		return nil, fmt.Errorf("erro ao testar conexão com o banco: %w", err)
 	}
 
-	return db, nil
+	return gormDB, nil
+}
+
+// Dialector escolhe o dialeto do GORM para o driver. É usado pelo servidor e pelos testes,
+// para que os repositórios rodem contra qualquer um dos bancos suportados.
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case configs.DriverSQLite:
+		return sqlite.Open(dsn), nil
+	case configs.DriverPostgres:
+		return postgres.Open(dsn), nil
+	case configs.DriverMySQL:
+		// Sem tamanho o MySQL cria strings como LONGTEXT, que não pode ser chave nem ter índice
+		return mysql.New(mysql.Config{DSN: MySQLDSN(dsn), DefaultStringSize: 255}), nil
+	}
+	return nil, fmt.Errorf("driver de banco desconhecido: %s", driver)
+}
+
+// SQLiteDSN acrescenta ao arquivo as opções de concorrência do driver:
+// _busy_timeout (em ms) faz uma escrita esperar a outra terminar em vez de falhar com
+// "database is locked", e _txlock=immediate reserva a escrita já no BEGIN, evitando o
+// impasse entre duas transações que leram e depois tentam escrever.
+func SQLiteDSN(file, timeout string) string {
+	if timeout == "" {
+		timeout = "5000"
+	}
+	separator := "?"
+	if strings.Contains(file, "?") {
+		separator = "&"
+	}
+	return file + separator + "_busy_timeout=" + timeout + "&_txlock=immediate"
+}
+
+// MySQLDSN liga parseTime, sem o qual o driver devolve DATETIME como []byte
+// e as colunas time.Time não podem ser lidas
+func MySQLDSN(dsn string) string {
+	if strings.Contains(dsn, "parseTime=") {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "parseTime=true"
 }
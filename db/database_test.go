@@ -0,0 +1,30 @@
+package db
+
+import (
+	"apis/configs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	assert.Equal(t, "test.db?_busy_timeout=5000&_txlock=immediate", SQLiteDSN("test.db", ""))
+	assert.Equal(t, "test.db?_busy_timeout=1000&_txlock=immediate", SQLiteDSN("test.db", "1000"))
+	assert.Equal(t, "file:test.db?cache=shared&_busy_timeout=5000&_txlock=immediate", SQLiteDSN("file:test.db?cache=shared", ""))
+}
+
+func TestMySQLDSN(t *testing.T) {
+	assert.Equal(t, "user:pass@tcp(localhost:3306)/apis?parseTime=true", MySQLDSN("user:pass@tcp(localhost:3306)/apis"))
+	assert.Equal(t, "user@/apis?charset=utf8mb4&parseTime=true", MySQLDSN("user@/apis?charset=utf8mb4"))
+	assert.Equal(t, "user@/apis?parseTime=false", MySQLDSN("user@/apis?parseTime=false"))
+}
+
+func TestDialector(t *testing.T) {
+	for _, driver := range []string{configs.DriverSQLite, configs.DriverPostgres, configs.DriverMySQL} {
+		dialector, err := Dialector(driver, "dsn")
+		assert.NoError(t, err)
+		assert.Equal(t, driver, dialector.Name())
+	}
+	_, err := Dialector("oracle", "dsn")
+	assert.Error(t, err)
+}
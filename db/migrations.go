@@ -0,0 +1,282 @@
+package db
+
+import (
+	"apis/pkg/money"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// goMigrations são as migrações que precisam ler os dados ou o esquema antes de mudar o
+// banco, o que não cabe num arquivo SQL: as conversões dos bancos criados pelo AutoMigrate
+// antes das migrações em SQL, que só alteram bancos antigos e não têm volta, e a
+// verificação de emails repetidos.
+var goMigrations = []Migration{
+	{Version: 1, Name: "product_timestamps", up: migrateProductTimestamps},
+	{Version: 2, Name: "money_amounts", up: migrateMoneyAmounts},
+	{Version: 3, Name: "legacy_columns", up: migrateLegacyColumns},
+	{Version: 5, Name: "unique_user_emails", up: migrateUniqueUserEmails, down: revertUniqueUserEmails},
+}
+
+// ErrDuplicateEmails impede o índice único de users.email enquanto houver contas repetidas
+var ErrDuplicateEmails = errors.New("há usuários com o mesmo email; junte ou altere as contas antes de migrar")
+
+// migrateProductTimestamps converte products.created_at, gravado como time.Time.GoString(),
+// em uma coluna datetime e adiciona updated_at e deleted_at.
+func migrateProductTimestamps(tx *gorm.DB, _ Options) error {
+	// Banco novo ou já no formato atual: o esquema inicial cuida do resto
+	if !tx.Migrator().HasTable("products") || tx.Migrator().HasColumn("products", "updated_at") {
+		return nil
+	}
+
+	var rows []struct {
+		ID        string
+		CreatedAt string
+	}
+	if err := tx.Table("products").Select("id, created_at").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, stmt := range []string{
+		"ALTER TABLE products ADD COLUMN created_at_new datetime",
+		"ALTER TABLE products ADD COLUMN updated_at datetime",
+		"ALTER TABLE products ADD COLUMN deleted_at datetime",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		createdAt, err := parseLegacyTime(row.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("produto %s: %w", row.ID, err)
+		}
+		err = tx.Exec("UPDATE products SET created_at_new = ?, updated_at = ? WHERE id = ?", createdAt, createdAt, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range []string{
+		"ALTER TABLE products DROP COLUMN created_at",
+		"ALTER TABLE products RENAME COLUMN created_at_new TO created_at",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateMoneyAmounts converte os preços float (products.price, order_items.unit_price e
+// orders.total) em unidades menores inteiras mais a moeda padrão.
+func migrateMoneyAmounts(tx *gorm.DB, opts Options) error {
+	if !money.IsValidCurrency(opts.DefaultCurrency) {
+		return fmt.Errorf("moeda padrão inválida: %q", opts.DefaultCurrency)
+	}
+	for _, c := range []struct{ table, column string }{
+		{"products", "price"},
+		{"order_items", "unit_price"},
+		{"orders", "total"},
+	} {
+		if err := convertMoneyColumn(tx, c.table, c.column, opts.DefaultCurrency); err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func convertMoneyColumn(tx *gorm.DB, table, column, currency string) error {
+	// Banco novo ou já no formato atual: o esquema inicial cuida do resto
+	if !tx.Migrator().HasTable(table) || !tx.Migrator().HasColumn(table, column) {
+		return nil
+	}
+
+	var rows []struct {
+		ID    string
+		Value sql.NullFloat64
+	}
+	if err := tx.Table(table).Select("id, " + column + " AS value").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, stmt := range []string{
+		"ALTER TABLE " + table + " ADD COLUMN " + column + "_amount integer",
+		"ALTER TABLE " + table + " ADD COLUMN " + column + "_currency text",
+	} {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		amount, err := money.FromFloat(row.Value.Float64, currency)
+		if err != nil {
+			return fmt.Errorf("registro %s: %w", row.ID, err)
+		}
+		err = tx.Exec("UPDATE "+table+" SET "+column+"_amount = ?, "+column+"_currency = ? WHERE id = ?", amount.Amount, amount.Currency, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + column).Error
+}
+
+// legacyColumns são as colunas criadas pelo AutoMigrate depois da tabela: um banco de uma
+// versão anterior tem a tabela sem elas, e o CREATE TABLE IF NOT EXISTS do esquema inicial
+// não as adiciona. Cada uma tem a definição por dialeto e, se indexada, o nome do índice.
+var legacyColumns = []struct {
+	table, column, index string
+	definition           map[string]string
+}{
+	{table: "users", column: "role", definition: map[string]string{
+		"sqlite": "text DEFAULT 'viewer'", "postgres": "text DEFAULT 'viewer'", "mysql": "varchar(255) DEFAULT 'viewer'",
+	}},
+	{table: "products", column: "owner_id", index: "idx_products_owner_id", definition: map[string]string{
+		"sqlite": "text", "postgres": "text", "mysql": "varchar(255)",
+	}},
+	{table: "products", column: "version", definition: map[string]string{
+		"sqlite": "integer NOT NULL DEFAULT 1", "postgres": "bigint NOT NULL DEFAULT 1", "mysql": "bigint NOT NULL DEFAULT 1",
+	}},
+	{table: "products", column: "category_id", index: "idx_products_category_id", definition: map[string]string{
+		"sqlite": "text", "postgres": "text", "mysql": "varchar(255)",
+	}},
+}
+
+// migrateLegacyColumns adiciona às tabelas já existentes as colunas de legacyColumns que
+// faltam, para que o esquema inicial consiga criar os índices e adotar o banco. Usuários
+// antigos ficam com o papel viewer e produtos antigos na versão 1, sem dono nem categoria.
+func migrateLegacyColumns(tx *gorm.DB, _ Options) error {
+	dialect := tx.Dialector.Name()
+	for _, c := range legacyColumns {
+		// Banco novo ou já no formato atual: o esquema inicial cuida do resto
+		if !tx.Migrator().HasTable(c.table) || tx.Migrator().HasColumn(c.table, c.column) {
+			continue
+		}
+		definition, ok := c.definition[dialect]
+		if !ok {
+			return fmt.Errorf("sem definição de %s.%s para o banco %s", c.table, c.column, dialect)
+		}
+		if err := tx.Exec("ALTER TABLE " + c.table + " ADD COLUMN " + c.column + " " + definition).Error; err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+		// No MySQL os índices do esquema inicial ficam dentro do CREATE TABLE, que é pulado
+		if c.index != "" && !tx.Migrator().HasIndex(c.table, c.index) {
+			if err := tx.Exec("CREATE INDEX " + c.index + " ON " + c.table + " (" + c.column + ")").Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// migrateUniqueUserEmails grava os emails em minúsculas e sem espaços e cria o índice único.
+// Emails que só diferem por maiúsculas ou espaços contam como repetidos; se houver algum a
+// migração falha listando os usuários, sem alterar nada.
+func migrateUniqueUserEmails(tx *gorm.DB, _ Options) error {
+	var users []struct {
+		ID    string
+		Email string
+	}
+	err := tx.Table("users").
+		Select("id, email").
+		Where("email IS NOT NULL AND LOWER(TRIM(email)) IN (?)", tx.Table("users").
+			Select("LOWER(TRIM(email))").
+			Where("email IS NOT NULL").
+			Group("LOWER(TRIM(email))").
+			Having("COUNT(*) > 1")).
+		Order("LOWER(TRIM(email)), id").
+		Scan(&users).Error
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		var report []string
+		for _, user := range users {
+			report = append(report, fmt.Sprintf("%s (%s)", user.Email, user.ID))
+		}
+		return fmt.Errorf("%w: %s", ErrDuplicateEmails, strings.Join(report, ", "))
+	}
+
+	if err := tx.Exec("UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email))").Error; err != nil {
+		return err
+	}
+	if tx.Migrator().HasIndex("users", "idx_users_email") {
+		return nil
+	}
+	return tx.Exec("CREATE UNIQUE INDEX idx_users_email ON users (email)").Error
+}
+
+// revertUniqueUserEmails remove o índice; os emails continuam normalizados, o que é válido
+func revertUniqueUserEmails(tx *gorm.DB, _ Options) error {
+	return tx.Migrator().DropIndex("users", "idx_users_email")
+}
+
+var goTimePattern = regexp.MustCompile(`^time\.Date\((\d+), time\.(\w+), (\d+), (\d+), (\d+), (\d+), (\d+), (.+)\)$`)
+
+// parseLegacyTime lê o formato de time.Time.GoString(), por exemplo
+// time.Date(2025, time.April, 8, 16, 36, 18, 123456789, time.Local).
+// Valores já em RFC 3339 também são aceitos.
+func parseLegacyTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	m := goTimePattern.FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("created_at em formato desconhecido: %q", value)
+	}
+
+	month, ok := parseMonth(m[2])
+	if !ok {
+		return time.Time{}, fmt.Errorf("mês inválido em created_at: %q", value)
+	}
+
+	var parts [6]int
+	for i, idx := range []int{1, 3, 4, 5, 6, 7} {
+		n, err := strconv.Atoi(m[idx])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("created_at inválido: %q", value)
+		}
+		parts[i] = n
+	}
+
+	loc, err := parseLegacyLocation(m[8])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(parts[0], month, parts[1], parts[2], parts[3], parts[4], parts[5], loc), nil
+}
+
+func parseMonth(name string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if m.String() == name {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func parseLegacyLocation(value string) (*time.Location, error) {
+	switch value {
+	case "time.Local":
+		return time.Local, nil
+	case "time.UTC":
+		return time.UTC, nil
+	}
+	var name string
+	if _, err := fmt.Sscanf(value, "time.Location(%q)", &name); err != nil {
+		return nil, fmt.Errorf("fuso horário inválido em created_at: %q", value)
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS `audit_entries`;
+DROP TABLE IF EXISTS `revoked_tokens`;
+DROP TABLE IF EXISTS `refresh_tokens`;
+DROP TABLE IF EXISTS `order_items`;
+DROP TABLE IF EXISTS `orders`;
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `stock_reservations`;
+DROP TABLE IF EXISTS `stock_movements`;
+DROP TABLE IF EXISTS `stock_levels`;
+DROP TABLE IF EXISTS `variants`;
+DROP TABLE IF EXISTS `categories`;
+DROP TABLE IF EXISTS `product_images`;
+DROP TABLE IF EXISTS `product_revisions`;
+DROP TABLE IF EXISTS `product_prices`;
+DROP TABLE IF EXISTS `product_tags`;
+DROP TABLE IF EXISTS `tags`;
+DROP TABLE IF EXISTS `products`;
@@ -0,0 +1,194 @@
+-- Esquema inicial, equivalente ao que o AutoMigrate criava. IF NOT EXISTS permite
+-- adotar bancos já criados pelo AutoMigrate sem recriar as tabelas; as colunas que
+-- faltam nas tabelas antigas são adicionadas antes, pela migração 3 (legacy_columns).
+
+CREATE TABLE IF NOT EXISTS `products` (
+    `id` varchar(255),
+    `name` varchar(255),
+    `price_amount` bigint,
+    `price_currency` varchar(255),
+    `owner_id` varchar(255),
+    `category_id` varchar(255),
+    `version` bigint NOT NULL DEFAULT 1,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_products_created_at` (`created_at`),
+    INDEX `idx_products_deleted_at` (`deleted_at`),
+    INDEX `idx_products_owner_id` (`owner_id`),
+    INDEX `idx_products_category_id` (`category_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` varchar(255),
+    `name` varchar(255),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_tags_name` (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `product_tags` (
+    `product_id` varchar(255),
+    `tag_id` varchar(255),
+    PRIMARY KEY (`product_id`,`tag_id`),
+    CONSTRAINT `fk_product_tags_product` FOREIGN KEY (`product_id`) REFERENCES `products`(`id`),
+    CONSTRAINT `fk_product_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `product_prices` (
+    `product_id` varchar(255),
+    `currency` varchar(255),
+    `amount` bigint,
+    PRIMARY KEY (`product_id`,`currency`),
+    CONSTRAINT `fk_products_prices` FOREIGN KEY (`product_id`) REFERENCES `products`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `product_revisions` (
+    `product_id` varchar(255),
+    `version` bigint,
+    `snapshot` text,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`product_id`,`version`),
+    INDEX `idx_product_revisions_created_at` (`created_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `product_images` (
+    `id` varchar(255),
+    `product_id` varchar(255),
+    `content_type` varchar(255),
+    `size` bigint,
+    `width` bigint,
+    `height` bigint,
+    `checksum` varchar(255),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_product_images_product_id` (`product_id`),
+    CONSTRAINT `fk_products_images` FOREIGN KEY (`product_id`) REFERENCES `products`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `categories` (
+    `id` varchar(255),
+    `name` varchar(255),
+    `parent_id` varchar(255),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_categories_parent_id` (`parent_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `variants` (
+    `id` varchar(255),
+    `product_id` varchar(255),
+    `sku` varchar(255),
+    `options` text,
+    `options_key` varchar(255),
+    `price_amount` bigint,
+    `price_currency` varchar(255),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_variants_product_id` (`product_id`),
+    UNIQUE INDEX `idx_variant_options` (`product_id`,`options_key`),
+    UNIQUE INDEX `idx_variants_sku` (`sku`),
+    CONSTRAINT `fk_products_variants` FOREIGN KEY (`product_id`) REFERENCES `products`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `stock_levels` (
+    `product_id` varchar(255),
+    `on_hand` bigint NOT NULL DEFAULT 0,
+    `reserved` bigint NOT NULL DEFAULT 0,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`product_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `stock_movements` (
+    `id` varchar(255),
+    `product_id` varchar(255),
+    `type` varchar(255),
+    `quantity` bigint,
+    `note` varchar(255),
+    `reservation_id` varchar(255),
+    `created_by` varchar(255),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_stock_movements_product_id` (`product_id`),
+    INDEX `idx_stock_movements_created_at` (`created_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `stock_reservations` (
+    `id` varchar(255),
+    `product_id` varchar(255),
+    `quantity` bigint,
+    `status` varchar(255),
+    `expires_at` datetime(3) NULL,
+    `created_by` varchar(255),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_stock_reservations_product_id` (`product_id`),
+    INDEX `idx_stock_reservations_status` (`status`),
+    INDEX `idx_stock_reservations_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` varchar(255),
+    `name` varchar(255),
+    `email` varchar(255),
+    `password` varchar(255),
+    `role` varchar(255) DEFAULT 'viewer',
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `orders` (
+    `id` varchar(255),
+    `user_id` varchar(255),
+    `status` varchar(255),
+    `total_amount` bigint,
+    `total_currency` varchar(255),
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `order_items` (
+    `id` varchar(255),
+    `order_id` varchar(255),
+    `product_id` varchar(255),
+    `quantity` bigint,
+    `unit_price_amount` bigint,
+    `unit_price_currency` varchar(255),
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_orders_items` FOREIGN KEY (`order_id`) REFERENCES `orders`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` varchar(255),
+    `user_id` varchar(255),
+    `token_hash` varchar(255),
+    `expires_at` datetime(3) NULL,
+    `revoked_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_refresh_tokens_user_id` (`user_id`),
+    UNIQUE INDEX `idx_refresh_tokens_token_hash` (`token_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (
+    `jti` varchar(255),
+    `expires_at` datetime(3) NULL,
+    PRIMARY KEY (`jti`),
+    INDEX `idx_revoked_tokens_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `audit_entries` (
+    `id` varchar(255),
+    `actor_id` varchar(255),
+    `action` varchar(255),
+    `entity_type` varchar(255),
+    `entity_id` varchar(255),
+    `changes` text,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_audit_entries_actor_id` (`actor_id`),
+    INDEX `idx_audit_entity` (`entity_type`,`entity_id`),
+    INDEX `idx_audit_entries_created_at` (`created_at`)
+);
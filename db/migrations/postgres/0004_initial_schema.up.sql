@@ -0,0 +1,194 @@
+-- Esquema inicial, equivalente ao que o AutoMigrate criava. IF NOT EXISTS permite
+-- adotar bancos já criados pelo AutoMigrate sem recriar as tabelas; as colunas que
+-- faltam nas tabelas antigas são adicionadas antes, pela migração 3 (legacy_columns).
+
+CREATE TABLE IF NOT EXISTS "products" (
+    "id" text,
+    "name" text,
+    "price_amount" bigint,
+    "price_currency" text,
+    "owner_id" text,
+    "category_id" text,
+    "version" bigint NOT NULL DEFAULT 1,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_products_deleted_at" ON "products" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_products_created_at" ON "products" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_products_category_id" ON "products" ("category_id");
+CREATE INDEX IF NOT EXISTS "idx_products_owner_id" ON "products" ("owner_id");
+
+CREATE TABLE IF NOT EXISTS "tags" (
+    "id" text,
+    "name" text,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_tags_name" ON "tags" ("name");
+
+CREATE TABLE IF NOT EXISTS "product_tags" (
+    "product_id" text,
+    "tag_id" text,
+    PRIMARY KEY ("product_id","tag_id"),
+    CONSTRAINT "fk_product_tags_product" FOREIGN KEY ("product_id") REFERENCES "products"("id"),
+    CONSTRAINT "fk_product_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "product_prices" (
+    "product_id" text,
+    "currency" text,
+    "amount" bigint,
+    PRIMARY KEY ("product_id","currency"),
+    CONSTRAINT "fk_products_prices" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "product_revisions" (
+    "product_id" text,
+    "version" bigint,
+    "snapshot" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("product_id","version")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_revisions_created_at" ON "product_revisions" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "product_images" (
+    "id" text,
+    "product_id" text,
+    "content_type" text,
+    "size" bigint,
+    "width" bigint,
+    "height" bigint,
+    "checksum" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_products_images" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_product_images_product_id" ON "product_images" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "categories" (
+    "id" text,
+    "name" text,
+    "parent_id" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_categories_parent_id" ON "categories" ("parent_id");
+
+CREATE TABLE IF NOT EXISTS "variants" (
+    "id" text,
+    "product_id" text,
+    "sku" text,
+    "options" text,
+    "options_key" text,
+    "price_amount" bigint,
+    "price_currency" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_products_variants" FOREIGN KEY ("product_id") REFERENCES "products"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_variants_sku" ON "variants" ("sku");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_variant_options" ON "variants" ("product_id","options_key");
+CREATE INDEX IF NOT EXISTS "idx_variants_product_id" ON "variants" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "stock_levels" (
+    "product_id" text,
+    "on_hand" bigint NOT NULL DEFAULT 0,
+    "reserved" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("product_id")
+);
+
+CREATE TABLE IF NOT EXISTS "stock_movements" (
+    "id" text,
+    "product_id" text,
+    "type" text,
+    "quantity" bigint,
+    "note" text,
+    "reservation_id" text,
+    "created_by" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_created_at" ON "stock_movements" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_stock_movements_product_id" ON "stock_movements" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "stock_reservations" (
+    "id" text,
+    "product_id" text,
+    "quantity" bigint,
+    "status" text,
+    "expires_at" timestamptz,
+    "created_by" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_expires_at" ON "stock_reservations" ("expires_at");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_status" ON "stock_reservations" ("status");
+CREATE INDEX IF NOT EXISTS "idx_stock_reservations_product_id" ON "stock_reservations" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" text,
+    "name" text,
+    "email" text,
+    "password" text,
+    "role" text DEFAULT 'viewer',
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" text,
+    "user_id" text,
+    "status" text,
+    "total_amount" bigint,
+    "total_currency" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "order_items" (
+    "id" text,
+    "order_id" text,
+    "product_id" text,
+    "quantity" bigint,
+    "unit_price_amount" bigint,
+    "unit_price_currency" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_orders_items" FOREIGN KEY ("order_id") REFERENCES "orders"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "refresh_tokens" (
+    "id" text,
+    "user_id" text,
+    "token_hash" text,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" (
+    "jti" text,
+    "expires_at" timestamptz,
+    PRIMARY KEY ("jti")
+);
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+
+CREATE TABLE IF NOT EXISTS "audit_entries" (
+    "id" text,
+    "actor_id" text,
+    "action" text,
+    "entity_type" text,
+    "entity_id" text,
+    "changes" text,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_created_at" ON "audit_entries" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_audit_entity" ON "audit_entries" ("entity_type","entity_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_entries_actor_id" ON "audit_entries" ("actor_id");
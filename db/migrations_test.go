@@ -0,0 +1,195 @@
+package db
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestParseLegacyTime(t *testing.T) {
+	want := time.Date(2025, time.April, 8, 16, 36, 18, 123456789, time.UTC)
+	got, err := parseLegacyTime(want.GoString())
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(got))
+
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.NoError(t, err)
+	want = time.Date(2025, time.December, 31, 23, 59, 59, 0, saoPaulo)
+	got, err = parseLegacyTime(want.GoString())
+	assert.NoError(t, err)
+	assert.True(t, want.Equal(got))
+
+	local := time.Now()
+	got, err = parseLegacyTime(local.GoString())
+	assert.NoError(t, err)
+	assert.True(t, local.Equal(got))
+
+	got, err = parseLegacyTime("2025-04-08T16:36:18Z")
+	assert.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2025, time.April, 8, 16, 36, 18, 0, time.UTC)))
+
+	_, err = parseLegacyTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestMigrateProductTimestamps(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "legacy.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Esquema original, criado pelo AutoMigrate antes de qualquer migração: preço em float,
+	// created_at gravado via GoString() e usuários sem papel
+	createdAt := time.Date(2025, time.April, 8, 16, 36, 18, 500, time.UTC)
+	for _, stmt := range []string{
+		"CREATE TABLE products (id text, name text, price real, created_at text, PRIMARY KEY (id))",
+		"CREATE TABLE users (id text, name text, email text, password text, PRIMARY KEY (id))",
+		"INSERT INTO users (id, name, email, password) VALUES ('6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a01', 'John', 'john@j.com', 'hash')",
+	} {
+		assert.NoError(t, gormDB.Exec(stmt).Error)
+	}
+	assert.NoError(t, gormDB.Exec("INSERT INTO products (id, name, price, created_at) VALUES (?, ?, ?, ?)",
+		"ae5e008e-f855-4590-b580-29f796c73536", "Legacy", 10.0, createdAt.GoString()).Error)
+
+	// A atualização completa, como o migrate up, deixa o banco pronto para o servidor
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	applied, err := migrator.Up(0)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.Migrations()))
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, status.Name)
+	}
+	assert.NoError(t, migrator.Check())
+
+	var product entity.Product
+	assert.NoError(t, gormDB.First(&product, "id = ?", "ae5e008e-f855-4590-b580-29f796c73536").Error)
+	assert.Equal(t, "Legacy", product.Name)
+	assert.Equal(t, money.New(1000, "BRL"), product.Price)
+	assert.Equal(t, 1, product.Version)
+	assert.Nil(t, product.CategoryID)
+	assert.True(t, createdAt.Equal(product.CreatedAt))
+	assert.True(t, createdAt.Equal(product.UpdatedAt))
+	assert.False(t, product.DeletedAt.Valid)
+
+	var user entity.User
+	assert.NoError(t, gormDB.First(&user, "id = ?", "6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a01").Error)
+	assert.Equal(t, entity.RoleViewer, user.Role)
+
+	// Rodar de novo não reaplica nada
+	applied, err = migrator.Up(0)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+	var count int64
+	gormDB.Model(&SchemaMigration{}).Count(&count)
+	assert.Equal(t, int64(len(migrator.Migrations())), count)
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "new.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, Migrate(gormDB, Options{DefaultCurrency: "BRL"}))
+
+	product, err := entity.NewProduct("New", money.New(1000, "BRL"))
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.Create(product).Error)
+}
+
+func TestMigrateMoneyAmounts(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "legacy.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Esquema com preços em float64
+	for _, stmt := range []string{
+		"CREATE TABLE products (id text, name text, price real, owner_id text, version integer, created_at datetime, updated_at datetime, deleted_at datetime, PRIMARY KEY (id))",
+		"CREATE TABLE orders (id text, user_id text, status text, total real, created_at datetime, PRIMARY KEY (id))",
+		"CREATE TABLE order_items (id text, order_id text, product_id text, quantity integer, unit_price real, PRIMARY KEY (id))",
+		"INSERT INTO products (id, name, price, version, created_at, updated_at) VALUES ('ae5e008e-f855-4590-b580-29f796c73536', 'Legacy', 1499.99, 1, '2025-04-08 16:36:18+00:00', '2025-04-08 16:36:18+00:00')",
+		"INSERT INTO orders (id, user_id, status, total, created_at) VALUES ('b7d3c1a0-5f4e-4d2b-9a8c-1e2f3a4b5c6d', 'c2d0c5a4-8b7e-4f6a-9d3c-2b1a0f9e8d7c', 'pending', 2999.98, '2025-04-08 16:36:18+00:00')",
+		"INSERT INTO order_items (id, order_id, product_id, quantity, unit_price) VALUES ('d4e5f6a7-b8c9-4d0e-8f1a-2b3c4d5e6f7a', 'b7d3c1a0-5f4e-4d2b-9a8c-1e2f3a4b5c6d', 'ae5e008e-f855-4590-b580-29f796c73536', 2, 1499.99)",
+	} {
+		assert.NoError(t, gormDB.Exec(stmt).Error)
+	}
+
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	_, err = migrator.Up(0)
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Check())
+
+	var product entity.Product
+	assert.NoError(t, gormDB.First(&product, "id = ?", "ae5e008e-f855-4590-b580-29f796c73536").Error)
+	assert.Equal(t, money.New(149999, "BRL"), product.Price)
+	assert.False(t, gormDB.Migrator().HasColumn("products", "price"))
+
+	var order entity.Order
+	assert.NoError(t, gormDB.Preload("Items").First(&order, "id = ?", "b7d3c1a0-5f4e-4d2b-9a8c-1e2f3a4b5c6d").Error)
+	assert.Equal(t, money.New(299998, "BRL"), order.Total)
+	assert.Len(t, order.Items, 1)
+	assert.Equal(t, money.New(149999, "BRL"), order.Items[0].UnitPrice)
+}
+
+func TestMigrateInvalidDefaultCurrency(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "new.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, Migrate(gormDB, Options{}))
+}
+
+func TestMigrateUniqueUserEmails(t *testing.T) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	_, err = migrator.Up(4)
+	assert.NoError(t, err)
+
+	// Contas gravadas antes da normalização, duas delas com o mesmo email
+	for _, stmt := range []string{
+		"INSERT INTO users (id, name, email) VALUES ('6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a01', 'John', 'John@J.com')",
+		"INSERT INTO users (id, name, email) VALUES ('6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a02', 'John', ' john@j.com')",
+		"INSERT INTO users (id, name, email) VALUES ('6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a03', 'Mary', 'Mary@J.com')",
+	} {
+		assert.NoError(t, gormDB.Exec(stmt).Error)
+	}
+
+	_, err = migrator.Up(0)
+	assert.ErrorIs(t, err, ErrDuplicateEmails)
+	assert.ErrorContains(t, err, "6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a01")
+	assert.ErrorContains(t, err, "6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a02")
+	assert.NotContains(t, err.Error(), "Mary")
+	// Nada foi alterado e a migração continua pendente
+	assert.ErrorIs(t, migrator.Check(), ErrPendingMigrations)
+	assert.False(t, gormDB.Migrator().HasIndex("users", "idx_users_email"))
+
+	assert.NoError(t, gormDB.Exec("UPDATE users SET email = 'john2@j.com' WHERE id = '6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a02'").Error)
+	_, err = migrator.Up(0)
+	assert.NoError(t, err)
+	assert.True(t, gormDB.Migrator().HasIndex("users", "idx_users_email"))
+
+	var user entity.User
+	assert.NoError(t, gormDB.First(&user, "id = ?", "6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a03").Error)
+	assert.Equal(t, "mary@j.com", user.Email)
+	assert.Error(t, gormDB.Exec("INSERT INTO users (id, name, email) VALUES ('6f1c1c9e-3b9a-4c55-9a7e-0d5b3c8e1a04', 'Mary', 'mary@j.com')").Error)
+
+	reverted, err := migrator.Down(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, reverted[0].Version)
+	assert.False(t, gormDB.Migrator().HasIndex("users", "idx_users_email"))
+}
@@ -0,0 +1,464 @@
+package db
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles são as migrações em SQL, uma pasta por dialeto (sqlite, postgres e mysql)
+// com os arquivos NNNN_nome.up.sql e NNNN_nome.down.sql
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// MigrationsDir é a pasta das migrações em SQL, relativa à raiz do projeto
+const MigrationsDir = "db/migrations"
+
+// Dialetos com migrações em SQL, na ordem em que o migrate create gera os arquivos
+var migrationDialects = []string{"sqlite", "postgres", "mysql"}
+
+var (
+	ErrDirtySchema       = errors.New("uma migração começou e não terminou; corrija o banco e remova a linha de schema_migrations")
+	ErrModifiedMigration = errors.New("o arquivo de uma migração já aplicada foi alterado")
+	ErrUnknownMigration  = errors.New("o banco tem uma migração que esta versão não conhece")
+	ErrPendingMigrations = errors.New("há migrações pendentes")
+	ErrIrreversible      = errors.New("a migração não pode ser revertida")
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// SchemaMigration registra as migrações versionadas já aplicadas no banco
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string `gorm:"not null;default:''"`    // SHA-256 do .up.sql e do .down.sql; vazio nas migrações em Go
+	Dirty     bool   `gorm:"not null;default:false"` // a migração começou e não terminou
+	AppliedAt time.Time
+}
+
+// Options são as configurações de que algumas migrações precisam
+type Options struct {
+	// DefaultCurrency é a moeda atribuída aos preços gravados antes da existência de money.Money
+	DefaultCurrency string
+}
+
+// Migration é uma migração versionada: um par de arquivos SQL do dialeto do banco ou,
+// nas primeiras versões, uma conversão de dados escrita em Go
+type Migration struct {
+	Version  int
+	Name     string
+	Checksum string
+	up       func(tx *gorm.DB, opts Options) error
+	down     func(tx *gorm.DB, opts Options) error // nil quando não há volta
+}
+
+// MigrationStatus é o estado de uma migração no banco
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	Modified  bool // o checksum gravado não bate com o arquivo atual
+	Unknown   bool // aplicada no banco, mas ausente nesta versão
+	AppliedAt *time.Time
+}
+
+// Migrator aplica e reverte as migrações de um banco
+type Migrator struct {
+	db         *gorm.DB
+	opts       Options
+	dialect    string
+	migrations []Migration
+}
+
+func NewMigrator(gormDB *gorm.DB, opts Options) (*Migrator, error) {
+	dialect := gormDB.Dialector.Name()
+	migrations, err := loadMigrations(migrationFiles, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: gormDB, opts: opts, dialect: dialect, migrations: migrations}, nil
+}
+
+// Migrate aplica, em ordem e uma única vez, todas as migrações pendentes
+func Migrate(gormDB *gorm.DB, opts Options) error {
+	migrator, err := NewMigrator(gormDB, opts)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(0)
+	return err
+}
+
+// Migrations retorna as migrações conhecidas para o dialeto do banco, em ordem de versão
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status compara as migrações conhecidas com as registradas em schema_migrations
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	known := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Migration: migration}
+		if record, ok := applied[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.Applied = true
+			status.Dirty = record.Dirty
+			status.Modified = record.Checksum != migration.Checksum
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		if known[version] {
+			continue
+		}
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: version, Name: record.Name, Checksum: record.Checksum},
+			Applied:   true,
+			Dirty:     record.Dirty,
+			Unknown:   true,
+			AppliedAt: &appliedAt,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check confere se o banco está exatamente na versão desta aplicação. O servidor se recusa
+// a subir com migrações pendentes, incompletas, alteradas ou desconhecidas.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	if err := checkApplied(statuses); err != nil {
+		return err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d", ErrPendingMigrations, pending)
+	}
+	return nil
+}
+
+// Up aplica as migrações pendentes até a versão target (0 aplica todas) e retorna as aplicadas
+func (m *Migrator) Up(target int) ([]Migration, error) {
+	if err := m.createTable(); err != nil {
+		return nil, err
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApplied(statuses); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, status := range statuses {
+		if status.Applied || (target > 0 && status.Version > target) {
+			continue
+		}
+		if err := m.apply(status.Migration); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// Down reverte as últimas steps migrações aplicadas e retorna as revertidas
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApplied(statuses); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+		if err := m.revert(status.Migration); err != nil {
+			return done, err
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// apply registra a migração como dirty, roda o up numa transação e limpa a marca no fim.
+// Onde o DDL é transacional uma falha desfaz tudo e o registro é apagado; no MySQL, que
+// confirma cada DDL, o registro fica dirty para indicar um esquema aplicado pela metade.
+func (m *Migrator) apply(migration Migration) error {
+	record := SchemaMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Checksum:  migration.Checksum,
+		Dirty:     true,
+		AppliedAt: time.Now(),
+	}
+	if err := m.db.Create(&record).Error; err != nil {
+		return fmt.Errorf("erro ao registrar a migração %d (%s): %w", migration.Version, migration.Name, err)
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.up(tx, m.opts); err != nil {
+			return err
+		}
+		return tx.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Update("dirty", false).Error
+	})
+	if err != nil {
+		if m.transactionalDDL() {
+			m.db.Delete(&SchemaMigration{}, migration.Version)
+		}
+		return fmt.Errorf("erro na migração %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(migration Migration) error {
+	if migration.down == nil {
+		return fmt.Errorf("%w: %d (%s)", ErrIrreversible, migration.Version, migration.Name)
+	}
+	if err := m.db.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Update("dirty", true).Error; err != nil {
+		return fmt.Errorf("erro ao registrar a reversão de %d (%s): %w", migration.Version, migration.Name, err)
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.down(tx, m.opts); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		if m.transactionalDDL() {
+			m.db.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Update("dirty", false)
+		}
+		return fmt.Errorf("erro ao reverter a migração %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// transactionalDDL diz se CREATE, ALTER e DROP podem ser desfeitos por rollback
+func (m *Migrator) transactionalDDL() bool {
+	return m.dialect != "mysql"
+}
+
+// createTable cria schema_migrations. É a única tabela mantida pelo AutoMigrate: ela precisa
+// existir antes de qualquer migração, e bancos anteriores a ela ganham checksum e dirty.
+func (m *Migrator) createTable() error {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("erro ao criar schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	applied := map[int]SchemaMigration{}
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return applied, nil
+	}
+	// Bancos anteriores ao checksum ainda não têm as colunas novas até o primeiro up
+	columns := []string{"version", "name", "applied_at"}
+	for _, column := range []string{"checksum", "dirty"} {
+		if m.db.Migrator().HasColumn(&SchemaMigration{}, column) {
+			columns = append(columns, column)
+		}
+	}
+	var records []SchemaMigration
+	if err := m.db.Select(columns).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("erro ao ler schema_migrations: %w", err)
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// checkApplied barra up, down e o servidor quando o banco não está num estado conhecido
+func checkApplied(statuses []MigrationStatus) error {
+	for _, status := range statuses {
+		switch {
+		case status.Dirty:
+			return fmt.Errorf("%w: %d (%s)", ErrDirtySchema, status.Version, status.Name)
+		case status.Unknown:
+			return fmt.Errorf("%w: %d (%s)", ErrUnknownMigration, status.Version, status.Name)
+		case status.Modified:
+			return fmt.Errorf("%w: %d (%s)", ErrModifiedMigration, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+// loadMigrations junta as migrações em Go com os arquivos SQL do dialeto
+func loadMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("sem migrações para o banco %s: %w", dialect, err)
+	}
+
+	migrations := append([]Migration{}, goMigrations...)
+	used := make(map[int]bool, len(goMigrations))
+	for _, migration := range goMigrations {
+		used[migration.Version] = true
+	}
+
+	names := map[int]string{}
+	files := map[int]map[string]string{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nome de migração inválido: %s", path.Join(dir, entry.Name()))
+		}
+		version, _ := strconv.Atoi(match[1])
+		if used[version] || (names[version] != "" && names[version] != match[2]) {
+			return nil, fmt.Errorf("versão de migração repetida: %d", version)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if files[version] == nil {
+			files[version] = map[string]string{}
+		}
+		names[version] = match[2]
+		files[version][match[3]] = string(content)
+	}
+
+	for version, pair := range files {
+		up, hasUp := pair["up"]
+		down, hasDown := pair["down"]
+		if !hasUp || !hasDown {
+			return nil, fmt.Errorf("a migração %d (%s) precisa dos arquivos .up.sql e .down.sql", version, names[version])
+		}
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     names[version],
+			Checksum: migrationChecksum(up, down),
+			up:       sqlStep(up),
+			down:     sqlStep(down),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// migrationChecksum cobre os dois arquivos: um .down.sql alterado depois de aplicado
+// reverteria algo diferente do que o .up.sql criou
+func migrationChecksum(up, down string) string {
+	sum := sha256.New()
+	sum.Write([]byte(up))
+	sum.Write([]byte{0}) // separa os arquivos, para que mover texto de um para o outro mude a soma
+	sum.Write([]byte(down))
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// sqlStep executa um arquivo de migração, um comando por vez. Os comandos terminam com ';'
+// no fim da linha; ';' dentro de strings ou no meio da linha não separa comandos.
+func sqlStep(content string) func(tx *gorm.DB, opts Options) error {
+	return func(tx *gorm.DB, _ Options) error {
+		for _, stmt := range splitStatements(content) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Create gera os arquivos vazios da próxima migração em dir, para todos os dialetos,
+// e retorna os caminhos criados
+func Create(dir, name string) ([]string, error) {
+	if !migrationNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("nome de migração inválido: %q (use letras minúsculas, números e _)", name)
+	}
+
+	next := 0
+	for _, migration := range goMigrations {
+		next = max(next, migration.Version)
+	}
+	for _, dialect := range migrationDialects {
+		entries, err := os.ReadDir(filepath.Join(dir, dialect))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if match := migrationFilePattern.FindStringSubmatch(entry.Name()); match != nil {
+				version, _ := strconv.Atoi(match[1])
+				next = max(next, version)
+			}
+		}
+	}
+	next++
+
+	var created []string
+	for _, dialect := range migrationDialects {
+		if err := os.MkdirAll(filepath.Join(dir, dialect), 0o755); err != nil {
+			return created, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			content := fmt.Sprintf("-- %s (%s): %s\n", name, dialect, direction)
+			if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+				return created, err
+			}
+			created = append(created, file)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"apis/internal/entity"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var entityModels = []interface{}{
+	&entity.Product{}, &entity.ProductPrice{}, &entity.ProductRevision{}, &entity.ProductImage{},
+	&entity.Category{}, &entity.Tag{}, &entity.Variant{}, &entity.StockLevel{}, &entity.StockMovement{},
+	&entity.StockReservation{}, &entity.User{}, &entity.Order{}, &entity.OrderItem{},
+	&entity.RefreshToken{}, &entity.RevokedToken{}, &entity.AuditEntry{},
+}
+
+func openMigratorTestDB(t *testing.T) *gorm.DB {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gormDB
+}
+
+func TestMigrationsMatchEntities(t *testing.T) {
+	gormDB := openMigratorTestDB(t)
+	assert.NoError(t, Migrate(gormDB, Options{DefaultCurrency: "BRL"}))
+
+	// Toda coluna mapeada pelas entidades precisa existir no esquema das migrações
+	for _, model := range entityModels {
+		stmt := &gorm.Statement{DB: gormDB}
+		assert.NoError(t, stmt.Parse(model))
+		assert.True(t, gormDB.Migrator().HasTable(stmt.Schema.Table), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, gormDB.Migrator().HasColumn(stmt.Schema.Table, field.DBName), stmt.Schema.Table+"."+field.DBName)
+		}
+		for _, rel := range stmt.Schema.Relationships.Relations {
+			if rel.JoinTable != nil {
+				assert.True(t, gormDB.Migrator().HasTable(rel.JoinTable.Table), rel.JoinTable.Table)
+			}
+		}
+	}
+}
+
+func TestMigratorCheck(t *testing.T) {
+	gormDB := openMigratorTestDB(t)
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(), ErrPendingMigrations)
+
+	applied, err := migrator.Up(0)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.Migrations()))
+	assert.NoError(t, migrator.Check())
+
+	// Arquivo alterado depois de aplicado
+	assert.NoError(t, gormDB.Model(&SchemaMigration{}).Where("version = ?", 4).Update("checksum", "outro").Error)
+	assert.ErrorIs(t, migrator.Check(), ErrModifiedMigration)
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[3].Modified)
+	_, err = migrator.Up(0)
+	assert.ErrorIs(t, err, ErrModifiedMigration)
+	assert.NoError(t, gormDB.Model(&SchemaMigration{}).Where("version = ?", 4).Update("checksum", statuses[3].Checksum).Error)
+
+	// Migração interrompida
+	assert.NoError(t, gormDB.Model(&SchemaMigration{}).Where("version = ?", 4).Update("dirty", true).Error)
+	assert.ErrorIs(t, migrator.Check(), ErrDirtySchema)
+	assert.NoError(t, gormDB.Model(&SchemaMigration{}).Where("version = ?", 4).Update("dirty", false).Error)
+
+	// Banco migrado por uma versão mais nova da aplicação
+	assert.NoError(t, gormDB.Create(&SchemaMigration{Version: 999, Name: "future"}).Error)
+	assert.ErrorIs(t, migrator.Check(), ErrUnknownMigration)
+}
+
+func TestMigratorAdoptsLegacyTable(t *testing.T) {
+	gormDB := openMigratorTestDB(t)
+
+	// Banco criado pelo AutoMigrate da versão anterior, com schema_migrations sem checksum nem dirty
+	assert.NoError(t, gormDB.AutoMigrate(entityModels...))
+	assert.NoError(t, gormDB.Exec("CREATE TABLE schema_migrations (version integer, name text, applied_at datetime, PRIMARY KEY (version))").Error)
+	assert.NoError(t, gormDB.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (1, 'product_timestamps', CURRENT_TIMESTAMP), (2, 'money_amounts', CURRENT_TIMESTAMP)").Error)
+
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, migrator.Check(), ErrPendingMigrations)
+
+	applied, err := migrator.Up(0)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.Migrations())-2)
+	assert.Equal(t, 3, applied[0].Version)
+	assert.NoError(t, migrator.Check())
+}
+
+func TestMigratorDown(t *testing.T) {
+	gormDB := openMigratorTestDB(t)
+	migrator, err := NewMigrator(gormDB, Options{DefaultCurrency: "BRL"})
+	assert.NoError(t, err)
+	_, err = migrator.Up(4)
+	assert.NoError(t, err)
+	assert.True(t, gormDB.Migrator().HasTable("products"))
+
+	reverted, err := migrator.Down(1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.Equal(t, 4, reverted[0].Version)
+	assert.False(t, gormDB.Migrator().HasTable("products"))
+
+	// As conversões em Go não têm volta
+	_, err = migrator.Down(1)
+	assert.ErrorIs(t, err, ErrIrreversible)
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[2].Applied)
+	assert.False(t, statuses[2].Dirty)
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- comentário
+CREATE TABLE a (
+  id integer
+);
+
+-- outro; com ponto e vírgula
+CREATE INDEX idx_a ON a (id);
+INSERT INTO a VALUES (1)`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (\n  id integer\n);",
+		"CREATE INDEX idx_a ON a (id);",
+		"INSERT INTO a VALUES (1)",
+	}, splitStatements(content))
+	assert.Empty(t, splitStatements("-- vazio\n"))
+}
+
+func TestMigrationChecksum(t *testing.T) {
+	load := func(up, down string) string {
+		migrations, err := loadMigrations(fstest.MapFS{
+			"migrations/sqlite/0100_example.up.sql":   {Data: []byte(up)},
+			"migrations/sqlite/0100_example.down.sql": {Data: []byte(down)},
+		}, "sqlite")
+		assert.NoError(t, err)
+		return migrations[len(migrations)-1].Checksum
+	}
+
+	checksum := load("CREATE TABLE a (id text);", "DROP TABLE a;")
+	assert.Equal(t, checksum, load("CREATE TABLE a (id text);", "DROP TABLE a;"))
+	// Alterar só o .down.sql também conta como arquivo alterado
+	assert.NotEqual(t, checksum, load("CREATE TABLE a (id text);", "DROP TABLE b;"))
+	assert.NotEqual(t, checksum, load("CREATE TABLE a (id text);DROP TABLE a;", ""))
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "sqlite"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sqlite", "0005_orders_index.up.sql"), nil, 0o644))
+
+	files, err := Create(dir, "add_email_index")
+	assert.NoError(t, err)
+	assert.Len(t, files, 6)
+	for _, dialect := range migrationDialects {
+		assert.FileExists(t, filepath.Join(dir, dialect, "0006_add_email_index.up.sql"))
+		assert.FileExists(t, filepath.Join(dir, dialect, "0006_add_email_index.down.sql"))
+	}
+
+	_, err = Create(dir, "Add Email")
+	assert.Error(t, err)
+}
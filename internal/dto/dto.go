@@ -1,8 +1,62 @@
 package dto
 
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// PriceInput aceita o formato antigo, um número como 1499.99 na moeda padrão, e o novo
+// {"amount": "1499.99", "currency": "BRL"}. O valor é lido como texto, sem passar por float.
+type PriceInput struct {
+	Amount   string `json:"amount" example:"1499.99"`
+	Currency string `json:"currency,omitempty" example:"BRL"`
+}
+
+func (p *PriceInput) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return money.ErrInvalidAmount
+		}
+		*p = PriceInput{Amount: number.String()}
+		return nil
+	}
+
+	type plain PriceInput
+	var value plain
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*p = PriceInput(value)
+	return nil
+}
+
+// Money converte o preço; sem currency vale a moeda padrão
+func (p PriceInput) Money(defaultCurrency string) (money.Money, error) {
+	if p.Amount == "" {
+		return money.Money{}, entity.ErrPriceIsRequired
+	}
+	currency := p.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return money.Parse(p.Amount, currency)
+}
+
+// NewPriceInput faz o caminho inverso, para montar documentos a partir de um produto
+func NewPriceInput(m money.Money) PriceInput {
+	return PriceInput{Amount: m.Decimal(), Currency: m.Currency}
+}
+
 type CreateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Name       string     `json:"name"`
+	Price      PriceInput `json:"price"`
+	CategoryID *string    `json:"category_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty" example:"promoção"` // nomes de tags existentes
 }
 
 type CreateUserInput struct {
@@ -11,9 +65,59 @@ type CreateUserInput struct {
 	Password string `json:"password"`
 }
 
+// PUT substitui o produto inteiro: nome e preço são obrigatórios e
+// categoria e tags ausentes deixam o produto sem categoria e sem tags
 type UpdateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Name       *string     `json:"name"`
+	Price      *PriceInput `json:"price"`
+	CategoryID *string     `json:"category_id,omitempty"`
+	Tags       []string    `json:"tags,omitempty" example:"promoção"`
+}
+
+// RevertProductInput escolhe a revisão para a qual o produto volta
+type RevertProductInput struct {
+	Version int `json:"version" example:"3"`
+}
+
+// ProductPatchDocument é o documento sobre o qual os patches de produto são aplicados.
+// Campos fora dele não podem ser alterados via PATCH.
+type ProductPatchDocument struct {
+	Name       string     `json:"name"`
+	Price      PriceInput `json:"price"`
+	CategoryID *string    `json:"category_id"`
+	Tags       []string   `json:"tags" example:"promoção"`
+}
+
+type CategoryInput struct {
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// VariantInput cria ou substitui uma variante; sem price a variante usa o preço do produto,
+// e um price sem currency usa a moeda do preço do produto
+type VariantInput struct {
+	SKU     string            `json:"sku" example:"TSHIRT-M-BLUE"`
+	Options map[string]string `json:"options"`
+	Price   *PriceInput       `json:"price,omitempty"`
+}
+
+type TagInput struct {
+	Name string `json:"name"`
+}
+
+// Campos nil não são alterados
+type UpdateUserInput struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+type SetRoleInput struct {
+	Role string `json:"role" example:"editor"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
 }
 
 type GetJWTInput struct {
@@ -22,5 +126,105 @@ type GetJWTInput struct {
 }
 
 type AccessTokenOutput struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type OrderItemInput struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type CreateOrderInput struct {
+	Items []OrderItemInput `json:"items"`
+}
+
+type ProductPageOutput struct {
+	Items      []entity.Product `json:"items"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	Total      int64            `json:"total"`
+	TotalPages int              `json:"total_pages"`
+}
+
+type AuditPageOutput struct {
+	Items      []entity.AuditEntry `json:"items"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	Total      int64               `json:"total"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+type ProductCursorPageOutput struct {
+	Items      []entity.Product `json:"items"`
+	Limit      int              `json:"limit"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// ProductImportError aponta a linha do arquivo que não foi importada e o motivo
+type ProductImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ProductImportOutput struct {
+	Imported int                  `json:"imported"`
+	Failed   int                  `json:"failed"`
+	Errors   []ProductImportError `json:"errors"`
+}
+
+// ProductBatchOperation é uma operação de POST /products/batch.
+// create e update exigem product; update e delete exigem id e aceitam version como If-Match.
+type ProductBatchOperation struct {
+	Op      string              `json:"op"`
+	ID      string              `json:"id,omitempty"`
+	Version *int                `json:"version,omitempty"`
+	Product *UpdateProductInput `json:"product,omitempty"`
+}
+
+type ProductBatchInput struct {
+	Operations []ProductBatchOperation `json:"operations"`
+}
+
+// ProductBatchResult usa os mesmos status HTTP que a rota individual retornaria
+type ProductBatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  int    `json:"status"`
+	ID      string `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ProductBatchOutput struct {
+	Atomic    bool                 `json:"atomic"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []ProductBatchResult `json:"results"`
+}
+
+// StockAdjustmentInput é um lançamento manual no livro de estoque.
+// Para receipt e sale quantity é o número de unidades; para correction é a variação, com sinal.
+type StockAdjustmentInput struct {
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"`
+	Note     string `json:"note"`
+}
+
+// StockReservationInput reserva unidades por ttl_seconds (padrão de 15 minutos)
+type StockReservationInput struct {
+	Quantity   int `json:"quantity"`
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+type StockOutput struct {
+	ProductID string    `json:"product_id"`
+	OnHand    int       `json:"on_hand"`
+	Reserved  int       `json:"reserved"`
+	Available int       `json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
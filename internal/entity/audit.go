@@ -0,0 +1,225 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidAuditAction = errors.New("Invalid audit action")
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	AuditPurge   AuditAction = "purge"
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditCreate, AuditUpdate, AuditDelete, AuditRestore, AuditPurge:
+		return true
+	}
+	return false
+}
+
+// Tipos de entidade registrados no log de auditoria
+const (
+	AuditProduct = "product"
+	AuditUser    = "user"
+)
+
+// AuditFields são os campos de uma entidade acompanhados pela auditoria, pelo nome no JSON
+type AuditFields map[string]interface{}
+
+// FieldChange é a alteração de um campo. Before fica vazio quando o campo passou a existir
+// (criação) e After quando deixou de existir (remoção definitiva). Campos sigilosos, como
+// a senha, aparecem sem os valores.
+type FieldChange struct {
+	Field  string          `json:"field" example:"price"`
+	Before json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+// FieldChanges é gravado como JSON numa coluna de texto
+type FieldChanges []FieldChange
+
+// AuditEntry registra quem fez o quê com uma entidade. As entradas nunca são alteradas.
+type AuditEntry struct {
+	ID         entity.ID    `json:"id"`
+	ActorID    *entity.ID   `json:"actor_id" gorm:"index"` // nil em ações do próprio sistema
+	Action     AuditAction  `json:"action" example:"update"`
+	EntityType string       `json:"entity_type" gorm:"index:idx_audit_entity" example:"product"`
+	EntityID   entity.ID    `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Changes    FieldChanges `json:"changes" gorm:"type:text"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// NewAuditEntry monta a entrada com a diferença entre before e after; use nil em before
+// na criação e em after na remoção definitiva
+func NewAuditEntry(actorID *entity.ID, action AuditAction, entityType string, entityID entity.ID, before, after AuditFields) (*AuditEntry, error) {
+	if !action.IsValid() {
+		return nil, ErrInvalidAuditAction
+	}
+	changes, err := DiffFields(before, after)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditEntry{
+		ID:         entity.NewID(),
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// DiffFields compara os campos pelo valor em JSON e retorna os que mudaram, em ordem alfabética
+func DiffFields(before, after AuditFields) (FieldChanges, error) {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := FieldChanges{}
+	for _, name := range names {
+		old, err := auditValue(before, name)
+		if err != nil {
+			return nil, err
+		}
+		new, err := auditValue(after, name)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(old, new) {
+			changes = append(changes, FieldChange{Field: name, Before: old, After: new})
+		}
+	}
+	return changes, nil
+}
+
+func auditValue(fields AuditFields, name string) (json.RawMessage, error) {
+	value, ok := fields[name]
+	if !ok {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// Value grava as alterações como JSON
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+func (c *FieldChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = FieldChanges{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported audit changes type %T", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Prefixed retorna os campos com "prefix." antes de cada nome. Variantes, imagens e estoque
+// fazem parte do produto, e as alterações deles entram no histórico dele com esses nomes.
+func (f AuditFields) Prefixed(prefix string) AuditFields {
+	if f == nil {
+		return nil
+	}
+	prefixed := make(AuditFields, len(f))
+	for name, value := range f {
+		prefixed[prefix+"."+name] = value
+	}
+	return prefixed
+}
+
+// AuditFields retorna os dados do produto que a auditoria acompanha
+func (p *Product) AuditFields() AuditFields {
+	prices := make(map[string]string, len(p.Prices))
+	for _, price := range p.Prices {
+		prices[price.Currency] = price.Money().Decimal()
+	}
+	tags := make([]string, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		tags = append(tags, tag.Name)
+	}
+	sort.Strings(tags)
+	return AuditFields{
+		"name":        p.Name,
+		"price":       p.Price,
+		"prices":      prices,
+		"owner_id":    p.OwnerID,
+		"category_id": p.CategoryID,
+		"tags":        tags,
+	}
+}
+
+// AuditFields retorna os dados do usuário que a auditoria acompanha; a senha fica de fora
+func (u *User) AuditFields() AuditFields {
+	return AuditFields{
+		"name":  u.Name,
+		"email": u.Email,
+		"role":  u.Role,
+	}
+}
+
+// AuditFields retorna os dados da variante que a auditoria acompanha, com o prefixo
+// "variants.<id>" usado no histórico do produto. Sem preço próprio o campo price fica de fora.
+func (v *Variant) AuditFields() AuditFields {
+	fields := AuditFields{
+		"sku":     v.SKU,
+		"options": v.Options,
+	}
+	if v.Price != nil {
+		fields["price"] = v.Price
+	}
+	return fields.Prefixed("variants." + v.ID.String())
+}
+
+// AuditFields retorna os dados da imagem que a auditoria acompanha, com o prefixo
+// "images.<id>" usado no histórico do produto
+func (i *ProductImage) AuditFields() AuditFields {
+	return AuditFields{
+		"content_type": i.ContentType,
+		"size":         i.Size,
+		"width":        i.Width,
+		"height":       i.Height,
+	}.Prefixed("images." + i.ID.String())
+}
+
+// AuditFields retorna o saldo que a auditoria acompanha; prefix é "stock" para o estoque
+// do produto e "variants.<id>.stock" para o de uma variante
+func (s *StockLevel) AuditFields(prefix string) AuditFields {
+	return AuditFields{
+		"on_hand":  s.OnHand,
+		"reserved": s.Reserved,
+	}.Prefixed(prefix)
+}
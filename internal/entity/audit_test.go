@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuditEntryDiff(t *testing.T) {
+	product, _ := NewProduct("Notebook", money.New(100000, "BRL"))
+	product.Tags = []Tag{{Name: "promo"}}
+	before := product.AuditFields()
+
+	product.Price = money.New(89990, "BRL")
+	product.Tags = nil
+	actor := entity.NewID()
+	entry, err := NewAuditEntry(&actor, AuditUpdate, AuditProduct, product.ID, before, product.AuditFields())
+	assert.NoError(t, err)
+	assert.Equal(t, &actor, entry.ActorID)
+	assert.Equal(t, product.ID, entry.EntityID)
+
+	// Só os campos alterados, em ordem alfabética
+	assert.Len(t, entry.Changes, 2)
+	assert.Equal(t, "price", entry.Changes[0].Field)
+	assert.JSONEq(t, `{"amount": "1000.00", "currency": "BRL"}`, string(entry.Changes[0].Before))
+	assert.JSONEq(t, `{"amount": "899.90", "currency": "BRL"}`, string(entry.Changes[0].After))
+	assert.Equal(t, "tags", entry.Changes[1].Field)
+	assert.JSONEq(t, `["promo"]`, string(entry.Changes[1].Before))
+	assert.JSONEq(t, `[]`, string(entry.Changes[1].After))
+}
+
+func TestNewAuditEntryCreate(t *testing.T) {
+	user, _ := NewUser("Ana", "ana@example.com", "123456")
+	entry, err := NewAuditEntry(&user.ID, AuditCreate, AuditUser, user.ID, nil, user.AuditFields())
+	assert.NoError(t, err)
+	assert.Len(t, entry.Changes, 3)
+	for _, change := range entry.Changes {
+		assert.Nil(t, change.Before)
+		assert.NotNil(t, change.After)
+	}
+
+	_, err = NewAuditEntry(nil, "rename", AuditUser, user.ID, nil, nil)
+	assert.Equal(t, ErrInvalidAuditAction, err)
+}
+
+func TestAuditFieldsOfProductParts(t *testing.T) {
+	product, _ := NewProduct("Camiseta", money.New(5000, "BRL"))
+	variant, _ := NewVariant(product.ID, "tshirt-m", map[string]string{"size": "M"}, nil)
+	before := variant.AuditFields()
+	variant.Set("TSHIRT-G", map[string]string{"size": "G"}, nil)
+
+	changes, err := DiffFields(before, variant.AuditFields())
+	assert.NoError(t, err)
+	prefix := "variants." + variant.ID.String()
+	assert.Equal(t, []string{prefix + ".options", prefix + ".sku"}, []string{changes[0].Field, changes[1].Field})
+
+	level := &StockLevel{ProductID: variant.ID, OnHand: 10}
+	fields := level.AuditFields(prefix + ".stock")
+	assert.Equal(t, 10, fields[prefix+".stock.on_hand"])
+	assert.Nil(t, AuditFields(nil).Prefixed("stock"))
+}
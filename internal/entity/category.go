@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrCategoryParentIsSelf = errors.New("A category cannot be its own parent")
+)
+
+// Category agrupa produtos; ParentID nil indica uma categoria raiz
+type Category struct {
+	ID        entity.ID  `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *entity.ID `json:"parent_id" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Children só é preenchido na listagem em árvore
+	Children []*Category `json:"children,omitempty" gorm:"-"`
+}
+
+func NewCategory(name string, parentID *entity.ID) (*Category, error) {
+	now := time.Now()
+	c := &Category{
+		ID:        entity.NewID(),
+		Name:      strings.TrimSpace(name),
+		ParentID:  parentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Category) Validate() error {
+	if c.ID.String() == "" {
+		return ErrIDISRequired
+	}
+	if _, err := entity.ParseID(c.ID.String()); err != nil {
+		return ErrInvalidId
+	}
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+	if c.ParentID != nil && *c.ParentID == c.ID {
+		return ErrCategoryParentIsSelf
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategory(t *testing.T) {
+	root, err := NewCategory(" Eletrônicos ", nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, root.ID)
+	assert.Equal(t, "Eletrônicos", root.Name)
+	assert.Nil(t, root.ParentID)
+
+	child, err := NewCategory("Notebooks", &root.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, root.ID, *child.ParentID)
+}
+
+func TestCategoryNameIsRequired(t *testing.T) {
+	category, err := NewCategory("  ", nil)
+	assert.Nil(t, category)
+	assert.Equal(t, ErrNameIsRequired, err)
+}
+
+func TestCategoryParentIsSelf(t *testing.T) {
+	category, err := NewCategory("Notebooks", nil)
+	assert.NoError(t, err)
+	category.ParentID = &category.ID
+	assert.Equal(t, ErrCategoryParentIsSelf, category.Validate())
+}
@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUserIDIsRequired      = errors.New("User ID is required")
+	ErrProductIsRequired     = errors.New("Product is required")
+	ErrOrderItemsIsRequired  = errors.New("Order must have at least one item")
+	ErrInvalidQuantity       = errors.New("Invalid quantity")
+	ErrQuantityTooLarge      = errors.New("Quantity must be at most 10000")
+	ErrOrderAlreadyCancelled = errors.New("Order already cancelled")
+)
+
+// MaxOrderItemQuantity limita a quantidade de um item do pedido
+const MaxOrderItemQuantity = 10000
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+type Order struct {
+	ID        entity.ID   `json:"id"`
+	UserID    entity.ID   `json:"user_id"`
+	Status    OrderStatus `json:"status"`
+	Items     []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
+	Total     money.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// OrderItem guarda uma cópia do preço do produto no momento da compra,
+// assim alterações posteriores no catálogo não mudam pedidos antigos.
+type OrderItem struct {
+	ID        entity.ID   `json:"id"`
+	OrderID   entity.ID   `json:"-"`
+	ProductID entity.ID   `json:"product_id"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice money.Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+}
+
+func NewOrderItem(product *Product, quantity int) (*OrderItem, error) {
+	if product == nil {
+		return nil, ErrProductIsRequired
+	}
+	item := &OrderItem{
+		ID:        entity.NewID(),
+		ProductID: product.ID,
+		Quantity:  quantity,
+		UnitPrice: product.Price,
+	}
+
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (i *OrderItem) Validate() error {
+	if i.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if i.Quantity > MaxOrderItemQuantity {
+		return ErrQuantityTooLarge
+	}
+	if err := i.UnitPrice.Validate(); err != nil {
+		return err
+	}
+	if !i.UnitPrice.IsPositive() {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+func NewOrder(userID entity.ID, items []OrderItem) (*Order, error) {
+	o := &Order{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		Status:    OrderStatusPending,
+		Items:     items,
+		CreatedAt: time.Now(),
+	}
+	for i := range o.Items {
+		o.Items[i].OrderID = o.ID
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	total, err := o.CalculateTotal()
+	if err != nil {
+		return nil, err
+	}
+	o.Total = total
+
+	return o, nil
+}
+
+// CalculateTotal soma os itens; todos precisam estar na mesma moeda
+func (o *Order) CalculateTotal() (money.Money, error) {
+	if len(o.Items) == 0 {
+		return money.Money{}, ErrOrderItemsIsRequired
+	}
+	total := money.New(0, o.Items[0].UnitPrice.Currency)
+	for _, item := range o.Items {
+		subtotal, err := item.UnitPrice.Mul(int64(item.Quantity))
+		if err != nil {
+			return money.Money{}, err
+		}
+		total, err = total.Add(subtotal)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (o *Order) Validate() error {
+	if o.ID.String() == "" {
+		return ErrIDISRequired
+	}
+	if o.UserID == (entity.ID{}) {
+		return ErrUserIDIsRequired
+	}
+	if len(o.Items) == 0 {
+		return ErrOrderItemsIsRequired
+	}
+	for _, item := range o.Items {
+		if err := item.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Order) Cancel() error {
+	if o.Status == OrderStatusCancelled {
+		return ErrOrderAlreadyCancelled
+	}
+	o.Status = OrderStatusCancelled
+	return nil
+}
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder(t *testing.T) {
+	p1, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	p2, _ := NewProduct("Product 2", money.New(250, "BRL"))
+	item1, err := NewOrderItem(p1, 2)
+	assert.Nil(t, err)
+	item2, err := NewOrderItem(p2, 4)
+	assert.Nil(t, err)
+
+	userID := entity.NewID()
+	order, err := NewOrder(userID, []OrderItem{*item1, *item2})
+	assert.Nil(t, err)
+	assert.NotNil(t, order)
+	assert.NotEmpty(t, order.ID)
+	assert.Equal(t, userID, order.UserID)
+	assert.Equal(t, OrderStatusPending, order.Status)
+	assert.Equal(t, money.New(3000, "BRL"), order.Total)
+	for _, item := range order.Items {
+		assert.Equal(t, order.ID, item.OrderID)
+	}
+}
+
+func TestOrderItemSnapshotsProductPrice(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	item, err := NewOrderItem(product, 1)
+	assert.Nil(t, err)
+
+	product.Price = money.New(9900, "BRL")
+	assert.Equal(t, money.New(1000, "BRL"), item.UnitPrice)
+}
+
+func TestOrderMixedCurrencies(t *testing.T) {
+	p1, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	p2, _ := NewProduct("Product 2", money.New(1000, "USD"))
+	item1, _ := NewOrderItem(p1, 1)
+	item2, _ := NewOrderItem(p2, 1)
+
+	order, err := NewOrder(entity.NewID(), []OrderItem{*item1, *item2})
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestOrderItemsIsRequired(t *testing.T) {
+	order, err := NewOrder(entity.NewID(), nil)
+	assert.Nil(t, order)
+	assert.Equal(t, ErrOrderItemsIsRequired, err)
+}
+
+func TestOrderUserIDIsRequired(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	item, _ := NewOrderItem(product, 1)
+	order, err := NewOrder(entity.ID{}, []OrderItem{*item})
+	assert.Nil(t, order)
+	assert.Equal(t, ErrUserIDIsRequired, err)
+}
+
+func TestOrderItemQuantityIsInvalid(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	item, err := NewOrderItem(product, 0)
+	assert.Nil(t, item)
+	assert.Equal(t, ErrInvalidQuantity, err)
+}
+
+func TestOrderItemQuantityIsTooLarge(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	item, err := NewOrderItem(product, MaxOrderItemQuantity+1)
+	assert.Nil(t, item)
+	assert.Equal(t, ErrQuantityTooLarge, err)
+
+	item, err = NewOrderItem(product, MaxOrderItemQuantity)
+	assert.Nil(t, err)
+	assert.Equal(t, MaxOrderItemQuantity, item.Quantity)
+}
+
+func TestOrderTotalOverflow(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(math.MaxInt64/2, "BRL"))
+	item, _ := NewOrderItem(product, 3)
+	order, err := NewOrder(entity.NewID(), []OrderItem{*item})
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, money.ErrAmountOverflow)
+
+	// Cada item cabe no int64, a soma não
+	item, _ = NewOrderItem(product, 2)
+	other, _ := NewOrderItem(product, 1)
+	order, err = NewOrder(entity.NewID(), []OrderItem{*item, *other})
+	assert.Nil(t, order)
+	assert.ErrorIs(t, err, money.ErrAmountOverflow)
+}
+
+func TestOrderCancel(t *testing.T) {
+	product, _ := NewProduct("Product 1", money.New(1000, "BRL"))
+	item, _ := NewOrderItem(product, 1)
+	order, _ := NewOrder(entity.NewID(), []OrderItem{*item})
+
+	assert.NoError(t, order.Cancel())
+	assert.Equal(t, OrderStatusCancelled, order.Status)
+	assert.Equal(t, ErrOrderAlreadyCancelled, order.Cancel())
+}
@@ -2,9 +2,11 @@ package entity
 
 import (
 	"apis/pkg/entity"
+	"apis/pkg/money"
 	"time"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 var (
@@ -13,21 +15,84 @@ var (
 	ErrNameIsRequired  = errors.New("Name is required")
 	ErrPriceIsRequired = errors.New("Price is required")
 	ErrInvalidPrice    = errors.New("Invalid price")
+	ErrBaseCurrency    = errors.New("The base price currency cannot be used in the price list")
 )
 
 type Product struct {
-	ID        entity.ID `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	CreatedAt string    `json:"created_at"`
+	ID         entity.ID      `json:"id"`
+	Name       string         `json:"name"`
+	Price      money.Money    `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Prices     []ProductPrice `json:"prices,omitempty" gorm:"foreignKey:ProductID"`
+	OwnerID    entity.ID      `json:"owner_id" gorm:"index"`
+	CategoryID *entity.ID     `json:"category_id" gorm:"index"`
+	Tags       []Tag          `json:"tags,omitempty" gorm:"many2many:product_tags"`
+	Variants   []Variant      `json:"variants,omitempty" gorm:"foreignKey:ProductID"` // só com ?include=variants
+	Images     []ProductImage `json:"images,omitempty" gorm:"foreignKey:ProductID"`
+	Version    int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string" format:"date-time"`
+
+	// LocalizedPrice só é preenchido quando a consulta pede uma moeda (?currency=)
+	LocalizedPrice *LocalizedPrice `json:"localized_price,omitempty" gorm:"-"`
+}
+
+// ProductPrice é um preço explícito do produto numa moeda diferente da do preço base
+type ProductPrice struct {
+	ProductID entity.ID `json:"-" gorm:"primaryKey"`
+	Currency  string    `json:"currency" gorm:"primaryKey" example:"USD"`
+	Amount    int64     `json:"amount" swaggertype:"string" example:"299.99"`
+}
+
+func NewProductPrice(productID entity.ID, price money.Money) (*ProductPrice, error) {
+	pp := &ProductPrice{ProductID: productID, Currency: price.Currency, Amount: price.Amount}
+	if err := pp.Validate(); err != nil {
+		return nil, err
+	}
+	return pp, nil
+}
+
+func (pp *ProductPrice) Validate() error {
+	price := pp.Money()
+	if err := price.Validate(); err != nil {
+		return err
+	}
+	if !price.IsPositive() {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+func (pp ProductPrice) Money() money.Money {
+	return money.New(pp.Amount, pp.Currency)
+}
+
+// MarshalJSON escreve o preço no mesmo formato de money.Money
+func (pp ProductPrice) MarshalJSON() ([]byte, error) {
+	return pp.Money().MarshalJSON()
+}
+
+// LocalizedPrice é o preço do produto na moeda pedida pelo cliente.
+// Converted indica que não há preço explícito e o valor veio da conversão do preço base.
+type LocalizedPrice struct {
+	Amount    string `json:"amount" example:"299.99"`
+	Currency  string `json:"currency" example:"USD"`
+	Converted bool   `json:"converted"`
+}
+
+func NewLocalizedPrice(price money.Money, converted bool) *LocalizedPrice {
+	return &LocalizedPrice{Amount: price.Decimal(), Currency: price.Currency, Converted: converted}
 }
 
-func NewProduct(name string, price float64) (*Product, error) {
+func NewProduct(name string, price money.Money) (*Product, error) {
+	now := time.Now()
 	p := &Product{
 		ID:        entity.NewID(),
 		Name:      name,
 		Price:     price,
-		CreatedAt: time.Now().GoString(),
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	if err := p.Validate(); err != nil {
@@ -48,8 +113,30 @@ func (p *Product) Validate() error {
 	if p.Name == "" {
 		return ErrNameIsRequired
 	}
-	if p.Price <= 0 {
+	if err := p.Price.Validate(); err != nil {
+		return err
+	}
+	if !p.Price.IsPositive() {
 		return ErrInvalidPrice
 	}
 	return nil
 }
+
+// PriceIn retorna o preço do produto na moeda informada: o preço base ou um preço explícito
+func (p *Product) PriceIn(currency string) (money.Money, bool) {
+	if p.Price.Currency == currency {
+		return p.Price, true
+	}
+	for _, price := range p.Prices {
+		if price.Currency == currency {
+			return price.Money(), true
+		}
+	}
+	return money.Money{}, false
+}
+
+// IsOwnedBy indica se o produto foi criado pelo usuário informado.
+// Produtos antigos, sem dono registrado, não pertencem a ninguém.
+func (p *Product) IsOwnedBy(userID entity.ID) bool {
+	return p.OwnerID != (entity.ID{}) && p.OwnerID == userID
+}
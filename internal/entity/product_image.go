@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("Unsupported image type: use JPEG, PNG or GIF")
+	ErrInvalidImageSize     = errors.New("Invalid image dimensions")
+)
+
+// MaxImagePixels limita a área das imagens aceitas, para que um arquivo pequeno e muito
+// comprimido não ocupe gigabytes de memória ao ser decodificado
+const MaxImagePixels = 25_000_000
+
+// ImageContentTypes são os formatos aceitos no upload
+var ImageContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// ProductImage descreve uma imagem de produto; o arquivo e a miniatura ficam numa BlobStore
+// sob BlobKey e ThumbnailKey. Checksum (SHA-256 do arquivo) é usado como ETag.
+type ProductImage struct {
+	ID          entity.ID `json:"id"`
+	ProductID   entity.ID `json:"-" gorm:"index"`
+	ContentType string    `json:"content_type" example:"image/jpeg"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Checksum    string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	URL          string `json:"url" gorm:"-" example:"/products/{id}/images/{image_id}"`
+	ThumbnailURL string `json:"thumbnail_url" gorm:"-" example:"/products/{id}/images/{image_id}/thumbnail"`
+}
+
+func NewProductImage(productID entity.ID, contentType string, size int64, width, height int, checksum string) (*ProductImage, error) {
+	i := &ProductImage{
+		ID:          entity.NewID(),
+		ProductID:   productID,
+		ContentType: contentType,
+		Size:        size,
+		Width:       width,
+		Height:      height,
+		Checksum:    checksum,
+		CreatedAt:   time.Now(),
+	}
+	if err := i.Validate(); err != nil {
+		return nil, err
+	}
+	i.setURLs()
+	return i, nil
+}
+
+func (i *ProductImage) Validate() error {
+	if !ImageContentTypes[i.ContentType] {
+		return ErrUnsupportedImageType
+	}
+	if i.Width <= 0 || i.Height <= 0 || i.Width*i.Height > MaxImagePixels {
+		return ErrInvalidImageSize
+	}
+	return nil
+}
+
+func (i *ProductImage) BlobKey() string {
+	return "products/" + i.ProductID.String() + "/" + i.ID.String()
+}
+
+func (i *ProductImage) ThumbnailKey() string {
+	return i.BlobKey() + "-thumbnail"
+}
+
+// ThumbnailContentType é o formato da miniatura: JPEG para fotos JPEG e PNG para o resto,
+// que pode ter transparência
+func (i *ProductImage) ThumbnailContentType() string {
+	if i.ContentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// AfterFind preenche as URLs, que não são guardadas no banco
+func (i *ProductImage) AfterFind(tx *gorm.DB) error {
+	i.setURLs()
+	return nil
+}
+
+func (i *ProductImage) setURLs() {
+	i.URL = "/products/" + i.ProductID.String() + "/images/" + i.ID.String()
+	i.ThumbnailURL = i.URL + "/thumbnail"
+}
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductImage(t *testing.T) {
+	productID := entity.NewID()
+	image, err := NewProductImage(productID, "image/png", 1024, 640, 480, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "products/"+productID.String()+"/"+image.ID.String(), image.BlobKey())
+	assert.Equal(t, "/products/"+productID.String()+"/images/"+image.ID.String(), image.URL)
+	assert.Equal(t, image.URL+"/thumbnail", image.ThumbnailURL)
+	assert.Equal(t, "image/png", image.ThumbnailContentType())
+}
+
+func TestProductImageIsInvalid(t *testing.T) {
+	_, err := NewProductImage(entity.NewID(), "image/svg+xml", 1024, 640, 480, "abc")
+	assert.Equal(t, ErrUnsupportedImageType, err)
+
+	_, err = NewProductImage(entity.NewID(), "image/jpeg", 1024, 0, 480, "abc")
+	assert.Equal(t, ErrInvalidImageSize, err)
+
+	_, err = NewProductImage(entity.NewID(), "image/jpeg", 1024, 10000, 10000, "abc")
+	assert.Equal(t, ErrInvalidImageSize, err)
+}
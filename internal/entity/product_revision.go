@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"time"
+)
+
+// ProductSnapshot são os dados versionados do produto. Dono, datas e imagens não entram.
+type ProductSnapshot struct {
+	Name       string        `json:"name"`
+	Price      money.Money   `json:"price"`
+	Prices     []money.Money `json:"prices"`
+	CategoryID *entity.ID    `json:"category_id"`
+	Tags       []Tag         `json:"tags"`
+}
+
+// ProductRevision é o estado do produto numa versão. Cada alteração que incrementa
+// Product.Version grava uma revisão, que nunca é alterada depois.
+type ProductRevision struct {
+	ProductID entity.ID       `json:"-" gorm:"primaryKey"`
+	Version   int             `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Snapshot  ProductSnapshot `json:"snapshot" gorm:"type:text;serializer:json"`
+	CreatedAt time.Time       `json:"created_at" gorm:"index"`
+}
+
+// NewProductRevision registra o estado atual do produto, com a data da última alteração
+func NewProductRevision(p *Product) *ProductRevision {
+	snapshot := ProductSnapshot{
+		Name:       p.Name,
+		Price:      p.Price,
+		Prices:     make([]money.Money, 0, len(p.Prices)),
+		CategoryID: p.CategoryID,
+		Tags:       make([]Tag, 0, len(p.Tags)),
+	}
+	for _, price := range p.Prices {
+		snapshot.Prices = append(snapshot.Prices, price.Money())
+	}
+	snapshot.Tags = append(snapshot.Tags, p.Tags...)
+	return &ProductRevision{
+		ProductID: p.ID,
+		Version:   p.Version,
+		Snapshot:  snapshot,
+		CreatedAt: p.UpdatedAt,
+	}
+}
+
+// Apply copia os dados da revisão para o produto. Version e UpdatedAt não mudam:
+// quem grava o resultado como nova versão é o banco.
+func (r *ProductRevision) Apply(p *Product) {
+	p.Name = r.Snapshot.Name
+	p.Price = r.Snapshot.Price
+	p.Prices = make([]ProductPrice, 0, len(r.Snapshot.Prices))
+	for _, price := range r.Snapshot.Prices {
+		p.Prices = append(p.Prices, ProductPrice{ProductID: p.ID, Currency: price.Currency, Amount: price.Amount})
+	}
+	p.CategoryID = r.Snapshot.CategoryID
+	p.Tags = append([]Tag{}, r.Snapshot.Tags...)
+}
@@ -1,37 +1,94 @@
 package entity
 
 import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewProduct(t *testing.T) {
-	product, err := NewProduct("Test Product", 10.0)
+	product, err := NewProduct("Test Product", money.New(1000, "BRL"))
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
 	assert.NotEmpty(t, product.ID)
 	assert.Equal(t, "Test Product", product.Name)
-	assert.Equal(t, 10.0, product.Price)
+	assert.Equal(t, money.New(1000, "BRL"), product.Price)
+	assert.Equal(t, 1, product.Version)
+	assert.False(t, product.CreatedAt.IsZero())
+	assert.Equal(t, product.CreatedAt, product.UpdatedAt)
+	assert.False(t, product.DeletedAt.Valid)
 }
 
 func TestProductNameIsRequired(t *testing.T) {
-	product, err := NewProduct("", 10.0)
+	product, err := NewProduct("", money.New(1000, "BRL"))
 	assert.Nil(t, product)
 	assert.NotNil(t, err)
 	assert.Equal(t, ErrNameIsRequired, err)
 }
 
 func TestProductPriceIsInvalid(t *testing.T) {
-	product, err := NewProduct("Test Product", -10.0)
+	product, err := NewProduct("Test Product", money.New(-1000, "BRL"))
 	assert.Nil(t, product) // valida que o produto não foi criado
 	assert.NotNil(t, err)  // valida que houve erro
 	assert.Equal(t, ErrInvalidPrice, err)
 }
 
+func TestProductCurrencyIsInvalid(t *testing.T) {
+	product, err := NewProduct("Test Product", money.New(1000, "XYZ"))
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	product, err = NewProduct("Test Product", money.New(1000, ""))
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+}
+
 func TestProductValidate(t *testing.T) {
-	product, err := NewProduct("Test Product", 10.0)
+	product, err := NewProduct("Test Product", money.New(1000, "BRL"))
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
 	assert.NoError(t, product.Validate())
 }
+
+func TestProductIsOwnedBy(t *testing.T) {
+	product, err := NewProduct("Test Product", money.New(1000, "BRL"))
+	assert.Nil(t, err)
+
+	ownerID := entity.NewID()
+	assert.False(t, product.IsOwnedBy(ownerID))
+	assert.False(t, product.IsOwnedBy(entity.ID{}))
+
+	product.OwnerID = ownerID
+	assert.True(t, product.IsOwnedBy(ownerID))
+	assert.False(t, product.IsOwnedBy(entity.NewID()))
+}
+
+func TestProductPriceIn(t *testing.T) {
+	product, err := NewProduct("Test Product", money.New(1000, "BRL"))
+	assert.NoError(t, err)
+
+	usd, err := NewProductPrice(product.ID, money.New(199, "USD"))
+	assert.NoError(t, err)
+	product.Prices = []ProductPrice{*usd}
+
+	price, ok := product.PriceIn("BRL")
+	assert.True(t, ok)
+	assert.Equal(t, money.New(1000, "BRL"), price)
+
+	price, ok = product.PriceIn("USD")
+	assert.True(t, ok)
+	assert.Equal(t, money.New(199, "USD"), price)
+
+	_, ok = product.PriceIn("EUR")
+	assert.False(t, ok)
+}
+
+func TestNewProductPriceIsInvalid(t *testing.T) {
+	_, err := NewProductPrice(entity.NewID(), money.New(0, "USD"))
+	assert.Equal(t, ErrInvalidPrice, err)
+
+	_, err = NewProductPrice(entity.NewID(), money.New(100, "XYZ"))
+	assert.Equal(t, money.ErrInvalidCurrency, err)
+}
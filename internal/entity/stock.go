@@ -0,0 +1,132 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidMovementType = errors.New("Invalid movement type: use receipt, sale or correction")
+	ErrInvalidReservation  = errors.New("Reservation must last between 1 second and 24 hours")
+)
+
+// StockMovementType é o motivo de um lançamento no livro de estoque
+type StockMovementType string
+
+const (
+	StockReceipt    StockMovementType = "receipt"    // entrada de mercadoria
+	StockSale       StockMovementType = "sale"       // saída por venda
+	StockCorrection StockMovementType = "correction" // ajuste de inventário, positivo ou negativo
+)
+
+// MaxReservationTTL limita por quanto tempo uma reserva pode segurar o estoque
+const MaxReservationTTL = 24 * time.Hour
+
+// StockLevel é o saldo atual de um produto. Só é alterado junto com um lançamento
+// em StockMovement ou com uma mudança de StockReservation, na mesma transação.
+// Variantes têm estoque próprio: nas três tabelas, ProductID guarda então o ID da variante.
+type StockLevel struct {
+	ProductID entity.ID `json:"product_id" gorm:"primaryKey"`
+	OnHand    int       `json:"on_hand" gorm:"not null;default:0"`  // unidades em estoque
+	Reserved  int       `json:"reserved" gorm:"not null;default:0"` // unidades seguras por reservas ativas
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Available é o que ainda pode ser vendido ou reservado
+func (s *StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// StockMovement é um lançamento do livro de estoque; lançamentos nunca são alterados.
+// Quantity é a variação do saldo: positiva para entradas e negativa para saídas.
+type StockMovement struct {
+	ID            entity.ID         `json:"id"`
+	ProductID     entity.ID         `json:"product_id" gorm:"index"`
+	Type          StockMovementType `json:"type"`
+	Quantity      int               `json:"quantity"`
+	Note          string            `json:"note,omitempty"`
+	ReservationID *entity.ID        `json:"reservation_id,omitempty"`
+	CreatedBy     entity.ID         `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"index"`
+}
+
+// NewStockMovement cria um lançamento. Para receipt e sale quantity é o número de unidades
+// (sempre positivo); para correction é a própria variação, com sinal.
+func NewStockMovement(productID entity.ID, movementType StockMovementType, quantity int, note string, createdBy entity.ID) (*StockMovement, error) {
+	delta := quantity
+	switch movementType {
+	case StockReceipt:
+		if quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+	case StockSale:
+		if quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		delta = -quantity
+	case StockCorrection:
+		if quantity == 0 {
+			return nil, ErrInvalidQuantity
+		}
+	default:
+		return nil, ErrInvalidMovementType
+	}
+	return &StockMovement{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		Type:      movementType,
+		Quantity:  delta,
+		Note:      note,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+// StockReservation segura unidades de um produto até ser confirmada (vira uma venda),
+// liberada ou expirar
+type StockReservation struct {
+	ID        entity.ID         `json:"id"`
+	ProductID entity.ID         `json:"product_id" gorm:"index"`
+	Quantity  int               `json:"quantity"`
+	Status    ReservationStatus `json:"status" gorm:"index"`
+	ExpiresAt time.Time         `json:"expires_at" gorm:"index"`
+	CreatedBy entity.ID         `json:"created_by"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func NewStockReservation(productID entity.ID, quantity int, ttl time.Duration, createdBy entity.ID) (*StockReservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if ttl < time.Second || ttl > MaxReservationTTL {
+		return nil, ErrInvalidReservation
+	}
+	now := time.Now()
+	return &StockReservation{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		Quantity:  quantity,
+		Status:    ReservationActive,
+		ExpiresAt: now.Add(ttl),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// IsActive indica se a reserva ainda segura o estoque no instante informado
+func (r *StockReservation) IsActive(now time.Time) bool {
+	return r.Status == ReservationActive && now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStockMovement(t *testing.T) {
+	productID, userID := entity.NewID(), entity.NewID()
+
+	receipt, err := NewStockMovement(productID, StockReceipt, 10, "NF 123", userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, receipt.Quantity)
+	assert.Equal(t, userID, receipt.CreatedBy)
+
+	sale, err := NewStockMovement(productID, StockSale, 3, "", userID)
+	assert.NoError(t, err)
+	assert.Equal(t, -3, sale.Quantity)
+
+	correction, err := NewStockMovement(productID, StockCorrection, -2, "quebra", userID)
+	assert.NoError(t, err)
+	assert.Equal(t, -2, correction.Quantity)
+}
+
+func TestNewStockMovementInvalid(t *testing.T) {
+	productID, userID := entity.NewID(), entity.NewID()
+
+	_, err := NewStockMovement(productID, StockReceipt, -1, "", userID)
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	_, err = NewStockMovement(productID, StockSale, 0, "", userID)
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	_, err = NewStockMovement(productID, StockCorrection, 0, "", userID)
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	_, err = NewStockMovement(productID, "gift", 1, "", userID)
+	assert.Equal(t, ErrInvalidMovementType, err)
+}
+
+func TestNewStockReservation(t *testing.T) {
+	reservation, err := NewStockReservation(entity.NewID(), 2, time.Minute, entity.NewID())
+	assert.NoError(t, err)
+	assert.Equal(t, ReservationActive, reservation.Status)
+	assert.True(t, reservation.IsActive(time.Now()))
+	assert.False(t, reservation.IsActive(time.Now().Add(2*time.Minute)))
+
+	_, err = NewStockReservation(entity.NewID(), 0, time.Minute, entity.NewID())
+	assert.Equal(t, ErrInvalidQuantity, err)
+
+	_, err = NewStockReservation(entity.NewID(), 1, 25*time.Hour, entity.NewID())
+	assert.Equal(t, ErrInvalidReservation, err)
+}
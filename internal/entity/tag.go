@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidTagName = errors.New("Tag name must have at most 50 characters and no commas")
+)
+
+// Tag é um rótulo livre; o nome é único e guardado em minúsculas
+type Tag struct {
+	ID   entity.ID `json:"id"`
+	Name string    `json:"name" gorm:"uniqueIndex"`
+}
+
+func NewTag(name string) (*Tag, error) {
+	t := &Tag{
+		ID:   entity.NewID(),
+		Name: NormalizeTagName(name),
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NormalizeTagName faz "Promoção " e "promoção" serem a mesma tag
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (t *Tag) Validate() error {
+	if t.Name == "" {
+		return ErrNameIsRequired
+	}
+	// Vírgulas ficam reservadas para listas de tags na query string
+	if len([]rune(t.Name)) > 50 || strings.Contains(t.Name, ",") {
+		return ErrInvalidTagName
+	}
+	return nil
+}
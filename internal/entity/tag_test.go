@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTag(t *testing.T) {
+	tag, err := NewTag("  Promoção ")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tag.ID)
+	assert.Equal(t, "promoção", tag.Name)
+}
+
+func TestTagNameIsInvalid(t *testing.T) {
+	_, err := NewTag("")
+	assert.Equal(t, ErrNameIsRequired, err)
+
+	_, err = NewTag("a,b")
+	assert.Equal(t, ErrInvalidTagName, err)
+
+	_, err = NewTag(strings.Repeat("a", 51))
+	assert.Equal(t, ErrInvalidTagName, err)
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRefreshTokenExpired = errors.New("Refresh token expired")
+	ErrRefreshTokenRevoked = errors.New("Refresh token revoked")
+)
+
+// RefreshToken é opaco para o cliente; no banco guardamos apenas o hash SHA-256
+type RefreshToken struct {
+	ID        entity.ID  `json:"id"`
+	UserID    entity.ID  `json:"user_id" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RevokedToken é a denylist de access tokens, indexada pelo claim "jti".
+// A entrada só precisa existir até o token expirar.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// NewRefreshToken gera um token aleatório e retorna a entidade junto com o valor em texto puro,
+// que deve ser entregue ao cliente e nunca persistido
+func NewRefreshToken(userID entity.ID, ttl time.Duration) (*RefreshToken, string, error) {
+	if userID == (entity.ID{}) {
+		return nil, "", ErrUserIDIsRequired
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	plain := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	return &RefreshToken{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		TokenHash: HashRefreshToken(plain),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, plain, nil
+}
+
+func HashRefreshToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *RefreshToken) Validate() error {
+	if t.RevokedAt != nil {
+		return ErrRefreshTokenRevoked
+	}
+	if time.Now().After(t.ExpiresAt) {
+		return ErrRefreshTokenExpired
+	}
+	return nil
+}
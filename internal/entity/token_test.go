@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRefreshToken(t *testing.T) {
+	userID := entity.NewID()
+	token, plain, err := NewRefreshToken(userID, time.Hour)
+	assert.Nil(t, err)
+	assert.NotNil(t, token)
+	assert.NotEmpty(t, plain)
+	assert.Equal(t, userID, token.UserID)
+	assert.Equal(t, HashRefreshToken(plain), token.TokenHash)
+	assert.NotEqual(t, plain, token.TokenHash)
+	assert.NoError(t, token.Validate())
+}
+
+func TestRefreshTokenIsUnique(t *testing.T) {
+	_, plain1, _ := NewRefreshToken(entity.NewID(), time.Hour)
+	_, plain2, _ := NewRefreshToken(entity.NewID(), time.Hour)
+	assert.NotEqual(t, plain1, plain2)
+}
+
+func TestRefreshTokenUserIDIsRequired(t *testing.T) {
+	token, _, err := NewRefreshToken(entity.ID{}, time.Hour)
+	assert.Nil(t, token)
+	assert.Equal(t, ErrUserIDIsRequired, err)
+}
+
+func TestRefreshTokenExpired(t *testing.T) {
+	token, _, err := NewRefreshToken(entity.NewID(), -time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRefreshTokenExpired, token.Validate())
+}
+
+func TestRefreshTokenRevoked(t *testing.T) {
+	token, _, err := NewRefreshToken(entity.NewID(), time.Hour)
+	assert.Nil(t, err)
+	now := time.Now()
+	token.RevokedAt = &now
+	assert.Equal(t, ErrRefreshTokenRevoked, token.Validate())
+}
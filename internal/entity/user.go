@@ -2,15 +2,41 @@ package entity
 
 import (
 	"apis/pkg/entity"
+	"strings"
 
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrInvalidRole        = errors.New("Invalid role")
+	ErrEmailIsRequired    = errors.New("Email is required")
+	ErrPasswordIsRequired = errors.New("Password is required")
+	ErrInvalidPassword    = errors.New("Invalid password")
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleViewer:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID       entity.ID `json:"id"`
 	Name     string    `json:"name"`
-	Email    string    `json:"email"`
+	Email    string    `json:"email" gorm:"uniqueIndex"`
 	Password string    `json:"-"`
+	Role     Role      `json:"role" gorm:"default:viewer"`
 }
 
 func NewUser(name, email, password string) (*User, error) {
@@ -21,11 +47,54 @@ func NewUser(name, email, password string) (*User, error) {
 	return &User{
 		ID:       entity.NewID(),
 		Name:     name,
-		Email:    email,
+		Email:    NormalizeEmail(email),
 		Password: string(hash),
+		Role:     RoleViewer,
 	}, nil
 }
 
+// NormalizeEmail faz " J@J.com" e "j@j.com" serem o mesmo email
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func (u *User) ComparePassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 }
+
+func (u *User) SetRole(role Role) error {
+	if !role.IsValid() {
+		return ErrInvalidRole
+	}
+	u.Role = role
+	return nil
+}
+
+func (u *User) Validate() error {
+	if u.Name == "" {
+		return ErrNameIsRequired
+	}
+	if u.Email == "" {
+		return ErrEmailIsRequired
+	}
+	if !u.Role.IsValid() {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+// ChangePassword só troca a senha se a senha atual conferir
+func (u *User) ChangePassword(current, new string) error {
+	if err := u.ComparePassword(current); err != nil {
+		return ErrInvalidPassword
+	}
+	if new == "" {
+		return ErrPasswordIsRequired
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(new), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
@@ -16,9 +16,58 @@ func TestNewUser(t *testing.T) {
 	assert.Equal(t, "j@j.com", user.Email)
 }
 
+func TestNewUserNormalizesEmail(t *testing.T) {
+	user, err := NewUser("John Doe", "  J@J.Com ", "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, "j@j.com", user.Email)
+	assert.Equal(t, NormalizeEmail("j@J.COM"), NormalizeEmail(" J@j.com"))
+}
+
 func Test_Validate_Password(t *testing.T) {
 	user, err := NewUser("John Doe", "j@j.com", "123456")
 	assert.Nil(t, err)
 	assert.NoError(t, user.ComparePassword("123456"))
 	assert.Error(t, user.ComparePassword("1234567"))
 }
+
+func TestNewUserDefaultsToViewer(t *testing.T) {
+	user, err := NewUser("John Doe", "j@j.com", "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, RoleViewer, user.Role)
+}
+
+func TestUserSetRole(t *testing.T) {
+	user, err := NewUser("John Doe", "j@j.com", "123456")
+	assert.Nil(t, err)
+	assert.NoError(t, user.SetRole(RoleEditor))
+	assert.Equal(t, RoleEditor, user.Role)
+	assert.Equal(t, ErrInvalidRole, user.SetRole("superuser"))
+	assert.Equal(t, RoleEditor, user.Role)
+}
+
+func TestUserValidate(t *testing.T) {
+	user, err := NewUser("John Doe", "j@j.com", "123456")
+	assert.Nil(t, err)
+	assert.NoError(t, user.Validate())
+
+	user.Name = ""
+	assert.Equal(t, ErrNameIsRequired, user.Validate())
+
+	user.Name = "John Doe"
+	user.Email = ""
+	assert.Equal(t, ErrEmailIsRequired, user.Validate())
+}
+
+func TestUserChangePassword(t *testing.T) {
+	user, err := NewUser("John Doe", "j@j.com", "123456")
+	assert.Nil(t, err)
+
+	assert.Equal(t, ErrInvalidPassword, user.ChangePassword("wrong", "654321"))
+	assert.NoError(t, user.ComparePassword("123456"))
+
+	assert.Equal(t, ErrPasswordIsRequired, user.ChangePassword("123456", ""))
+
+	assert.NoError(t, user.ChangePassword("123456", "654321"))
+	assert.NoError(t, user.ComparePassword("654321"))
+	assert.Error(t, user.ComparePassword("123456"))
+}
@@ -0,0 +1,149 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSKUIsRequired        = errors.New("SKU is required")
+	ErrInvalidSKU           = errors.New("SKU must have at most 64 letters, digits, '-', '_' or '.'")
+	ErrInvalidVariantOption = errors.New("Variant options need a name and a value of at most 50 characters")
+)
+
+var skuPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9._-]{0,63}$`)
+
+// VariantOptions são os atributos que distinguem a variante, como {"size": "M", "color": "blue"}
+type VariantOptions map[string]string
+
+// Variant é uma versão vendável de um produto (tamanho, cor...), com SKU único.
+// Sem Price a variante usa o preço do produto.
+type Variant struct {
+	ID         entity.ID      `json:"id"`
+	ProductID  entity.ID      `json:"product_id" gorm:"index;uniqueIndex:idx_variant_options"`
+	SKU        string         `json:"sku" gorm:"uniqueIndex" example:"TSHIRT-M-BLUE"`
+	Options    VariantOptions `json:"options" gorm:"type:text"`
+	OptionsKey string         `json:"-" gorm:"uniqueIndex:idx_variant_options"`
+	Price      *money.Money   `json:"price,omitempty" gorm:"embedded;embeddedPrefix:price_"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func NewVariant(productID entity.ID, sku string, options map[string]string, price *money.Money) (*Variant, error) {
+	now := time.Now()
+	v := &Variant{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	v.Set(sku, options, price)
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Set troca SKU, opções e preço, normalizando-os como NewVariant; chame Validate em seguida
+func (v *Variant) Set(sku string, options map[string]string, price *money.Money) {
+	v.SKU = NormalizeSKU(sku)
+	v.Options = make(VariantOptions, len(options))
+	for name, value := range options {
+		v.Options[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	v.OptionsKey = v.Options.Key()
+	v.Price = price
+}
+
+// NormalizeSKU faz "tshirt-m " e "TSHIRT-M" serem o mesmo SKU
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
+
+// Key descreve as opções em ordem fixa ("color=blue;size=M"), para que duas variantes
+// do mesmo produto não tenham a mesma combinação
+func (o VariantOptions) Key() string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + o[name]
+	}
+	return strings.Join(pairs, ";")
+}
+
+// Value grava as opções como JSON
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(o)
+	return string(data), err
+}
+
+func (o *VariantOptions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*o = VariantOptions{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported variant options type %T", value)
+	}
+	return json.Unmarshal(data, o)
+}
+
+func (v *Variant) Validate() error {
+	if v.ID.String() == "" {
+		return ErrIDISRequired
+	}
+	if _, err := entity.ParseID(v.ID.String()); err != nil {
+		return ErrInvalidId
+	}
+	if v.SKU == "" {
+		return ErrSKUIsRequired
+	}
+	if !skuPattern.MatchString(v.SKU) {
+		return ErrInvalidSKU
+	}
+	for name, value := range v.Options {
+		// "=" e ";" separam as opções em OptionsKey
+		if name == "" || value == "" || len([]rune(name)) > 50 || len([]rune(value)) > 50 ||
+			strings.ContainsAny(name, "=;") || strings.ContainsAny(value, "=;") {
+			return ErrInvalidVariantOption
+		}
+	}
+	if v.Price != nil {
+		if err := v.Price.Validate(); err != nil {
+			return err
+		}
+		if !v.Price.IsPositive() {
+			return ErrInvalidPrice
+		}
+	}
+	return nil
+}
+
+// EffectivePrice é o preço da variante ou, sem ele, o do produto
+func (v *Variant) EffectivePrice(product *Product) money.Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
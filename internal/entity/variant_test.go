@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVariant(t *testing.T) {
+	productID := entity.NewID()
+	variant, err := NewVariant(productID, " tshirt-m-blue ", map[string]string{" Size ": "M", "color": " blue"}, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, variant.ID)
+	assert.Equal(t, productID, variant.ProductID)
+	assert.Equal(t, "TSHIRT-M-BLUE", variant.SKU)
+	assert.Equal(t, VariantOptions{"size": "M", "color": "blue"}, variant.Options)
+	assert.Equal(t, "color=blue;size=M", variant.OptionsKey)
+}
+
+func TestVariantIsInvalid(t *testing.T) {
+	productID := entity.NewID()
+
+	_, err := NewVariant(productID, "", nil, nil)
+	assert.Equal(t, ErrSKUIsRequired, err)
+
+	_, err = NewVariant(productID, "TSHIRT M", nil, nil)
+	assert.Equal(t, ErrInvalidSKU, err)
+
+	_, err = NewVariant(productID, "TSHIRT-M", map[string]string{"size": ""}, nil)
+	assert.Equal(t, ErrInvalidVariantOption, err)
+
+	_, err = NewVariant(productID, "TSHIRT-M", map[string]string{"size": "M;L"}, nil)
+	assert.Equal(t, ErrInvalidVariantOption, err)
+
+	zero := money.New(0, "BRL")
+	_, err = NewVariant(productID, "TSHIRT-M", nil, &zero)
+	assert.Equal(t, ErrInvalidPrice, err)
+
+	invalid := money.New(100, "XXX")
+	_, err = NewVariant(productID, "TSHIRT-M", nil, &invalid)
+	assert.Equal(t, money.ErrInvalidCurrency, err)
+}
+
+func TestVariantEffectivePrice(t *testing.T) {
+	product, _ := NewProduct("T-shirt", money.New(4990, "BRL"))
+
+	variant, _ := NewVariant(product.ID, "TSHIRT-M", nil, nil)
+	assert.Equal(t, product.Price, variant.EffectivePrice(product))
+
+	override := money.New(5490, "BRL")
+	variant, _ = NewVariant(product.ID, "TSHIRT-XL", nil, &override)
+	assert.Equal(t, override, variant.EffectivePrice(product))
+}
@@ -0,0 +1,44 @@
+// Package blob guarda arquivos binários (imagens de produtos) atrás de uma BlobStore,
+// para que o armazenamento possa ser trocado sem mudar quem o usa.
+package blob
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("Blob not found")
+	ErrInvalidKey = errors.New("Invalid blob key")
+)
+
+// Info descreve um blob guardado
+type Info struct {
+	Key         string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// BlobStore guarda blobs por chave. Chaves são caminhos relativos separados por "/",
+// como "products/<id>/<imagem>"; Put substitui o blob se a chave já existir.
+type BlobStore interface {
+	Put(key, contentType string, data io.Reader) (*Info, error)
+	Get(key string) (io.ReadCloser, *Info, error)
+	Delete(key string) error
+}
+
+// ValidateKey recusa chaves vazias, absolutas ou que saiam da raiz do armazenamento
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package blob
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStores(t *testing.T) {
+	fileStore, err := NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for name, store := range map[string]BlobStore{"memory": NewMemoryStore(), "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			info, err := store.Put("products/1/image", "image/png", strings.NewReader("png data"))
+			assert.NoError(t, err)
+			assert.Equal(t, int64(8), info.Size)
+
+			reader, info, err := store.Get("products/1/image")
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, "png data", string(data))
+			assert.Equal(t, "image/png", info.ContentType)
+			assert.Equal(t, int64(8), info.Size)
+
+			// Put substitui o conteúdo
+			_, err = store.Put("products/1/image", "image/jpeg", strings.NewReader("jpeg"))
+			assert.NoError(t, err)
+			reader, info, err = store.Get("products/1/image")
+			assert.NoError(t, err)
+			data, _ = io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, "jpeg", string(data))
+			assert.Equal(t, "image/jpeg", info.ContentType)
+
+			assert.NoError(t, store.Delete("products/1/image"))
+			_, _, err = store.Get("products/1/image")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, store.Delete("products/1/image"), ErrNotFound)
+
+			for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../b", "a//b", `a\b`} {
+				_, err := store.Put(key, "text/plain", strings.NewReader("x"))
+				assert.ErrorIs(t, err, ErrInvalidKey, key)
+			}
+		})
+	}
+}
+
+func TestFileStoreConcurrentPut(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, content := range []string{strings.Repeat("a", 100000), strings.Repeat("b", 100000)} {
+		wg.Add(1)
+		go func(content string) {
+			defer wg.Done()
+			_, err := store.Put("image", "text/plain", strings.NewReader(content))
+			assert.NoError(t, err)
+		}(content)
+	}
+	wg.Wait()
+
+	// O arquivo final é um dos dois conteúdos inteiros, nunca uma mistura
+	reader, _, err := store.Get("image")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Len(t, data, 100000)
+	assert.True(t, strings.Count(string(data), string(data[0])) == len(data))
+}
@@ -0,0 +1,135 @@
+package blob
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStore guarda cada blob como um arquivo em Dir, com o content type num arquivo
+// ".meta" ao lado. O conteúdo é escrito num arquivo temporário e renomeado no fim,
+// para que uma leitura concorrente nunca veja um arquivo pela metade.
+type FileStore struct {
+	Dir string
+}
+
+type fileMeta struct {
+	ContentType string `json:"content_type"`
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) Put(key, contentType string, data io.Reader) (*Info, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	meta, err := json.Marshal(fileMeta{ContentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(path+".meta", func(f *os.File) error {
+		_, err := f.Write(meta)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(path, func(f *os.File) error {
+		_, err := io.Copy(f, data)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{Key: key, ContentType: contentType, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+func (s *FileStore) Get(key string) (io.ReadCloser, *Info, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	info := &Info{Key: key, Size: stat.Size(), ModTime: stat.ModTime()}
+	if data, err := os.ReadFile(path + ".meta"); err == nil {
+		var meta fileMeta
+		if json.Unmarshal(data, &meta) == nil {
+			info.ContentType = meta.ContentType
+		}
+	}
+	return file, info, nil
+}
+
+func (s *FileStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := os.Remove(path + ".meta"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	// o sufixo é reservado para os arquivos de metadados
+	if strings.HasSuffix(key, ".meta") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+// writeAtomic escreve num arquivo temporário da mesma pasta e o renomeia para path
+func writeAtomic(path string, write func(f *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,60 @@
+package blob
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore guarda os blobs em memória; serve para testes e para rodar sem disco
+type MemoryStore struct {
+	mu    sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+type memoryBlob struct {
+	data []byte
+	info Info
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{blobs: map[string]memoryBlob{}}
+}
+
+func (s *MemoryStore) Put(key, contentType string, data io.Reader) (*Info, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	info := Info{Key: key, ContentType: contentType, Size: int64(len(content)), ModTime: time.Now()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = memoryBlob{data: content, info: info}
+	return &info, nil
+}
+
+func (s *MemoryStore) Get(key string) (io.ReadCloser, *Info, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	blob, ok := s.blobs[key]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	info := blob.info
+	return io.NopCloser(bytes.NewReader(blob.data)), &info, nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.blobs, key)
+	return nil
+}
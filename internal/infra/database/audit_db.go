@@ -0,0 +1,98 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditQuery filtra o log de auditoria. Campos zerados não filtram nada.
+type AuditQuery struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     entity.AuditAction
+	Since      *time.Time
+	Until      *time.Time
+	Page       int
+	Limit      int
+}
+
+func (q *AuditQuery) Validate() error {
+	if q.Action != "" && !q.Action.IsValid() {
+		return entity.ErrInvalidAuditAction
+	}
+	if q.Since != nil && q.Until != nil && q.Since.After(*q.Until) {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+func (q *AuditQuery) filter(db *gorm.DB) *gorm.DB {
+	if q.EntityType != "" {
+		db = db.Where("entity_type = ?", q.EntityType)
+	}
+	if q.EntityID != "" {
+		db = db.Where("entity_id = ?", q.EntityID)
+	}
+	if q.ActorID != "" {
+		db = db.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		db = db.Where("action = ?", q.Action)
+	}
+	if q.Since != nil {
+		db = db.Where("created_at >= ?", *q.Since)
+	}
+	if q.Until != nil {
+		db = db.Where("created_at <= ?", *q.Until)
+	}
+	return db
+}
+
+// Audit é o log de auditoria. Só permite incluir e consultar: as entradas nunca são alteradas.
+type Audit struct {
+	DB *gorm.DB
+}
+
+func NewAudit(db *gorm.DB) *Audit {
+	return &Audit{DB: db}
+}
+
+// Record grava as entradas numa única transação
+func (a *Audit) Record(entries ...*entity.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return a.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(entries, 100).Error
+	})
+}
+
+// Search retorna as entradas da mais recente para a mais antiga
+func (a *Audit) Search(query AuditQuery) ([]entity.AuditEntry, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	entries := []entity.AuditEntry{}
+	db := query.filter(a.DB).Order("created_at desc").Order("id desc")
+	if query.Page > 0 && query.Limit > 0 {
+		db = db.Offset((query.Page - 1) * query.Limit).Limit(query.Limit)
+	}
+	if err := db.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (a *Audit) Count(query AuditQuery) (int64, error) {
+	if err := query.Validate(); err != nil {
+		return 0, err
+	}
+	var total int64
+	if err := query.filter(a.DB.Model(&entity.AuditEntry{})).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+	auditDB := NewAudit(db)
+
+	actor := entitypkg.NewID()
+	productID := entitypkg.NewID()
+	created, _ := entity.NewAuditEntry(&actor, entity.AuditCreate, entity.AuditProduct, productID, nil, entity.AuditFields{"name": "Mouse"})
+	updated, _ := entity.NewAuditEntry(&actor, entity.AuditUpdate, entity.AuditProduct, productID, entity.AuditFields{"name": "Mouse"}, entity.AuditFields{"name": "Mouse Pad"})
+	updated.CreatedAt = created.CreatedAt.Add(time.Second)
+	other, _ := entity.NewAuditEntry(nil, entity.AuditPurge, entity.AuditProduct, entitypkg.NewID(), entity.AuditFields{"name": "Teclado"}, nil)
+	other.CreatedAt = created.CreatedAt.Add(2 * time.Second)
+	assert.NoError(t, auditDB.Record(created, updated, other))
+
+	// Histórico do produto, do mais recente para o mais antigo, com as alterações de volta do JSON
+	history, err := auditDB.Search(AuditQuery{EntityType: entity.AuditProduct, EntityID: productID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, updated.ID, history[0].ID)
+	assert.Equal(t, "name", history[0].Changes[0].Field)
+	assert.JSONEq(t, `"Mouse Pad"`, string(history[0].Changes[0].After))
+
+	entries, err := auditDB.Search(AuditQuery{ActorID: actor.String(), Action: entity.AuditCreate})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, created.ID, entries[0].ID)
+
+	since := created.CreatedAt.Add(time.Second)
+	total, err := auditDB.Count(AuditQuery{Since: &since})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	entries, err = auditDB.Search(AuditQuery{Page: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, created.ID, entries[0].ID)
+
+	// Ações do sistema ficam sem autor
+	entries, err = auditDB.Search(AuditQuery{EntityID: other.EntityID.String()})
+	assert.NoError(t, err)
+	assert.Nil(t, entries[0].ActorID)
+
+	_, err = auditDB.Search(AuditQuery{Action: "rename"})
+	assert.ErrorIs(t, err, entity.ErrInvalidAuditAction)
+}
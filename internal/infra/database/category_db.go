@@ -0,0 +1,139 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrParentCategoryNotFound = errors.New("Parent category not found")
+	ErrCategoryCycle          = errors.New("A category cannot be moved under one of its descendants")
+	ErrCategoryHasChildren    = errors.New("Category has subcategories")
+)
+
+type Category struct {
+	DB *gorm.DB
+}
+
+func NewCategory(db *gorm.DB) *Category {
+	return &Category{DB: db}
+}
+
+func (c *Category) Create(category *entity.Category) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkParentCategory(tx, category); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+func (c *Category) GetByID(id string) (*entity.Category, error) {
+	var category entity.Category
+	if err := c.DB.Where("id = ?", id).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetAll retorna todas as categorias, em ordem alfabética
+func (c *Category) GetAll() ([]entity.Category, error) {
+	var categories []entity.Category
+	if err := c.DB.Order("name").Order("id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Update grava nome e pai; mover a categoria para dentro da própria subárvore é rejeitado
+func (c *Category) Update(category *entity.Category) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewCategory(tx).GetByID(category.ID.String()); err != nil {
+			return err
+		}
+		if err := checkParentCategory(tx, category); err != nil {
+			return err
+		}
+		if category.ParentID != nil {
+			subtree, err := NewCategory(tx).SubtreeIDs(category.ID.String())
+			if err != nil {
+				return err
+			}
+			for _, id := range subtree {
+				if id == category.ParentID.String() {
+					return ErrCategoryCycle
+				}
+			}
+		}
+		return tx.Model(category).Select("name", "parent_id", "updated_at").Updates(category).Error
+	})
+}
+
+// Delete remove uma categoria sem subcategorias; os produtos dela ficam sem categoria, ganham
+// uma nova versão com revisão e são retornados antes e depois da alteração
+func (c *Category) Delete(id string) ([]ProductChange, error) {
+	var changes []ProductChange
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		category, err := NewCategory(tx).GetByID(id)
+		if err != nil {
+			return err
+		}
+		var children int64
+		if err := tx.Model(&entity.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+		// Inclui os produtos na lixeira, para que uma restauração não aponte para uma categoria inexistente
+		products, err := findProducts(tx, "category_id = ?", id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&entity.Product{}).Where("category_id = ?", id).Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		changes = make([]ProductChange, len(products))
+		for i := range products {
+			after := products[i]
+			after.CategoryID = nil
+			changes[i] = ProductChange{Before: &products[i], After: &after}
+		}
+		if err := saveProductChanges(tx, changes); err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SubtreeIDs retorna o ID da categoria e de todas as suas descendentes
+func (c *Category) SubtreeIDs(id string) ([]string, error) {
+	var ids []string
+	// UNION (e não UNION ALL) descarta repetições e garante que a recursão termina
+	err := c.DB.Raw(`WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+	) SELECT id FROM subtree`, id).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func checkParentCategory(tx *gorm.DB, category *entity.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	_, err := NewCategory(tx).GetByID(category.ParentID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrParentCategoryNotFound
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCategoryTree(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	categoryDB := NewCategory(db)
+	root, _ := entity.NewCategory("Eletrônicos", nil)
+	child, _ := entity.NewCategory("Computadores", &root.ID)
+	grandchild, _ := entity.NewCategory("Notebooks", &child.ID)
+	other, _ := entity.NewCategory("Casa", nil)
+	for _, category := range []*entity.Category{root, child, grandchild, other} {
+		assert.NoError(t, categoryDB.Create(category))
+	}
+
+	subtree, err := categoryDB.SubtreeIDs(root.ID.String())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{root.ID.String(), child.ID.String(), grandchild.ID.String()}, subtree)
+
+	subtree, err = categoryDB.SubtreeIDs(grandchild.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{grandchild.ID.String()}, subtree)
+
+	all, err := categoryDB.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+	assert.Equal(t, "Casa", all[0].Name)
+
+	unknownParent := entitypkg.NewID()
+	orphan, _ := entity.NewCategory("Órfã", &unknownParent)
+	assert.ErrorIs(t, categoryDB.Create(orphan), ErrParentCategoryNotFound)
+}
+
+func TestUpdateCategory(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	categoryDB := NewCategory(db)
+	root, _ := entity.NewCategory("Eletrônicos", nil)
+	child, _ := entity.NewCategory("Computadores", &root.ID)
+	other, _ := entity.NewCategory("Casa", nil)
+	for _, category := range []*entity.Category{root, child, other} {
+		assert.NoError(t, categoryDB.Create(category))
+	}
+
+	// Mover a raiz para baixo da própria filha criaria um ciclo
+	root.ParentID = &child.ID
+	assert.ErrorIs(t, categoryDB.Update(root), ErrCategoryCycle)
+
+	child.Name = "Informática"
+	child.ParentID = &other.ID
+	assert.NoError(t, categoryDB.Update(child))
+	found, err := categoryDB.GetByID(child.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Informática", found.Name)
+	assert.Equal(t, other.ID, *found.ParentID)
+
+	// Voltar a ser raiz
+	child.ParentID = nil
+	assert.NoError(t, categoryDB.Update(child))
+	found, _ = categoryDB.GetByID(child.ID.String())
+	assert.Nil(t, found.ParentID)
+}
+
+func TestDeleteCategory(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	categoryDB := NewCategory(db)
+	root, _ := entity.NewCategory("Eletrônicos", nil)
+	child, _ := entity.NewCategory("Computadores", &root.ID)
+	assert.NoError(t, categoryDB.Create(root))
+	assert.NoError(t, categoryDB.Create(child))
+
+	product, _ := entity.NewProduct("Notebook", money.New(500000, "BRL"))
+	product.CategoryID = &child.ID
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.Create(product))
+
+	_, err = categoryDB.Delete(root.ID.String())
+	assert.ErrorIs(t, err, ErrCategoryHasChildren)
+	changes, err := categoryDB.Delete(child.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, &child.ID, changes[0].Before.CategoryID)
+	assert.Nil(t, changes[0].After.CategoryID)
+	rootChanges, err := categoryDB.Delete(root.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, rootChanges)
+	_, err = categoryDB.Delete(root.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// O produto ganha uma nova versão, com a revisão sem a categoria
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Nil(t, found.CategoryID)
+	assert.Equal(t, 2, found.Version)
+	assert.Equal(t, 2, changes[0].After.Version)
+	revision, err := productDB.GetRevision(product.ID.String(), 2)
+	assert.NoError(t, err)
+	assert.Nil(t, revision.Snapshot.CategoryID)
+}
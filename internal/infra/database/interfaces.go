@@ -1,16 +1,108 @@
 package database
 
-import "apis/internal/entity"
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"time"
+)
 
 type UserInterface interface {
 	Create(user *entity.User) error
 	GetByEmail(email string) (*entity.User, error)
+	GetByID(id string) (*entity.User, error)
+	Update(user *entity.User) error
+	Delete(id string) error
 }
 
 type ProductInterface interface {
 	Create(product *entity.Product) error
+	CreateBatch(products []*entity.Product) error
 	GetAll(page, limit int, sort string) ([]entity.Product, error)
+	Search(query ProductQuery) ([]entity.Product, error)
+	Count(query ProductQuery) (int64, error)
 	GetByID(id string) (*entity.Product, error)
 	Update(id string, product *entity.Product) error
+	Revert(product *entity.Product) error
+	Revisions(productID string) ([]entity.ProductRevision, error)
+	GetRevision(productID string, version int) (*entity.ProductRevision, error)
+	GetAsOf(id string, asOf time.Time) (*entity.Product, error)
+	SetPrice(product *entity.Product, price money.Money) error
+	DeletePrice(product *entity.Product, currency string) error
+	Delete(id string, version int) error
+	Restore(id string) error
+	Purge(id string) error
+	PurgeDeletedBefore(cutoff time.Time) ([]string, error)
+}
+
+type CategoryInterface interface {
+	Create(category *entity.Category) error
+	GetByID(id string) (*entity.Category, error)
+	GetAll() ([]entity.Category, error)
+	Update(category *entity.Category) error
+	Delete(id string) ([]ProductChange, error)
+	SubtreeIDs(id string) ([]string, error)
+}
+
+type TagInterface interface {
+	Create(tag *entity.Tag) error
+	GetByID(id string) (*entity.Tag, error)
+	GetByNames(names []string) ([]entity.Tag, error)
+	GetAll() ([]entity.Tag, error)
+	Update(tag *entity.Tag) error
+	Delete(id string) ([]ProductChange, error)
+}
+
+type VariantInterface interface {
+	Create(variant *entity.Variant) error
+	GetByID(id string) (*entity.Variant, error)
+	GetByProduct(productID string) ([]entity.Variant, error)
+	Update(variant *entity.Variant) error
+	Delete(id string) error
+}
+
+type ProductImageInterface interface {
+	Create(image *entity.ProductImage) error
+	GetByID(id string) (*entity.ProductImage, error)
+	GetByProduct(productID string) ([]entity.ProductImage, error)
 	Delete(id string) error
+	Orphans(limit int) ([]entity.ProductImage, error)
+}
+
+type StockInterface interface {
+	Level(productID string) (*entity.StockLevel, error)
+	Adjust(movement *entity.StockMovement) (*entity.StockLevel, error)
+	Movements(productID string, page, limit int) ([]entity.StockMovement, error)
+	Reserve(reservation *entity.StockReservation) error
+	GetReservation(id string) (*entity.StockReservation, error)
+	Release(id string) (*entity.StockReservation, error)
+	Commit(id string, createdBy entitypkg.ID) (*entity.StockMovement, error)
+	ReleaseExpired(now time.Time) (int64, error)
+}
+
+type OrderInterface interface {
+	Create(order *entity.Order) error
+	GetAllByUser(userID string, page, limit int, sort string) ([]entity.Order, error)
+	GetByID(id string) (*entity.Order, error)
+	Update(order *entity.Order) error
+}
+
+type AuditInterface interface {
+	Record(entries ...*entity.AuditEntry) error
+	Search(query AuditQuery) ([]entity.AuditEntry, error)
+	Count(query AuditQuery) (int64, error)
+}
+
+type TransactorInterface interface {
+	Transaction(fn func(tx *Repositories) error) error
+}
+
+type TokenInterface interface {
+	CreateRefreshToken(token *entity.RefreshToken) error
+	GetRefreshTokenByHash(hash string) (*entity.RefreshToken, error)
+	RotateRefreshToken(old, new *entity.RefreshToken) error
+	RevokeRefreshToken(id string) error
+	RevokeAllRefreshTokens(userID string) error
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
 }
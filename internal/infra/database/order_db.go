@@ -0,0 +1,60 @@
+package database
+
+import (
+	"apis/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type Order struct {
+	DB *gorm.DB
+}
+
+func NewOrder(db *gorm.DB) *Order {
+	return &Order{DB: db}
+}
+
+// Create grava o pedido e seus itens na mesma transação
+func (o *Order) Create(order *entity.Order) error {
+	return o.DB.Create(order).Error
+}
+
+func (o *Order) GetByID(id string) (*entity.Order, error) {
+	var order entity.Order
+	err := o.DB.Preload("Items").Where("id = ?", id).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (o *Order) GetAllByUser(userID string, page, limit int, sort string) ([]entity.Order, error) {
+	var orders []entity.Order
+
+	// Sanitiza o valor de sort
+	if sort != "asc" && sort != "desc" {
+		sort = "desc"
+	}
+
+	query := o.DB.Preload("Items").Where("user_id = ?", userID).Order("created_at " + sort)
+
+	// Aplica paginação se necessário
+	if page > 0 && limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+
+	if err := query.Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Update persiste apenas o status; itens e total são imutáveis depois de criados
+func (o *Order) Update(order *entity.Order) error {
+	_, err := o.GetByID(order.ID.String())
+	if err != nil {
+		return err
+	}
+	return o.DB.Model(order).Update("status", order.Status).Error
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func createTestOrder(t *testing.T, db *gorm.DB, userID entitypkg.ID, price money.Money, quantity int) *entity.Order {
+	product, err := entity.NewProduct("Test Product", price)
+	assert.NoError(t, err)
+	db.Create(product)
+
+	item, err := entity.NewOrderItem(product, quantity)
+	assert.NoError(t, err)
+	order, err := entity.NewOrder(userID, []entity.OrderItem{*item})
+	assert.NoError(t, err)
+	return order
+}
+
+func TestCreateOrder(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	order := createTestOrder(t, db, entitypkg.NewID(), money.New(1000, "BRL"), 3)
+	orderDB := NewOrder(db)
+	err = orderDB.Create(order)
+	assert.NoError(t, err)
+
+	orderFound, err := orderDB.GetByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, order.UserID, orderFound.UserID)
+	assert.Equal(t, money.New(3000, "BRL"), orderFound.Total)
+	assert.Len(t, orderFound.Items, 1)
+	assert.Equal(t, money.New(1000, "BRL"), orderFound.Items[0].UnitPrice)
+	assert.Equal(t, 3, orderFound.Items[0].Quantity)
+}
+
+func TestGetAllOrdersByUser(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	orderDB := NewOrder(db)
+	userID := entitypkg.NewID()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, orderDB.Create(createTestOrder(t, db, userID, money.New(1000, "BRL"), 1)))
+	}
+	assert.NoError(t, orderDB.Create(createTestOrder(t, db, entitypkg.NewID(), money.New(1000, "BRL"), 1)))
+
+	orders, err := orderDB.GetAllByUser(userID.String(), 0, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, orders, 3)
+	for _, order := range orders {
+		assert.Equal(t, userID, order.UserID)
+		assert.Len(t, order.Items, 1)
+	}
+
+	orders, err = orderDB.GetAllByUser(userID.String(), 1, 2, "asc")
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+}
+
+func TestCancelOrder(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	order := createTestOrder(t, db, entitypkg.NewID(), money.New(1000, "BRL"), 1)
+	orderDB := NewOrder(db)
+	assert.NoError(t, orderDB.Create(order))
+
+	assert.NoError(t, order.Cancel())
+	assert.NoError(t, orderDB.Update(order))
+
+	orderFound, err := orderDB.GetByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderStatusCancelled, orderFound.Status)
+	assert.Len(t, orderFound.Items, 1)
+}
@@ -2,10 +2,24 @@ package database
 
 import (
 	"apis/internal/entity"
+	"apis/pkg/money"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict indica que o produto foi alterado por outra requisição depois de lido
+var ErrVersionConflict = errors.New("Version conflict")
+
+// ProductChange é um produto antes e depois de uma alteração feita a partir de outra
+// entidade, como a remoção de uma categoria ou tag, para que ela possa ser auditada
+type ProductChange struct {
+	Before *entity.Product
+	After  *entity.Product
+}
+
 type Product struct {
 	DB *gorm.DB
 }
@@ -15,55 +29,399 @@ func NewProduct(db *gorm.DB) *Product {
 	return &Product{DB: db}
 }
 
+// Create grava o produto junto com a revisão da primeira versão
 func (p *Product) Create(product *entity.Product) error {
-	return p.DB.Create(product).Error
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return tx.Create(entity.NewProductRevision(product)).Error
+	})
+}
+
+// CreateBatch insere os produtos numa única transação: ou entram todos ou nenhum
+func (p *Product) CreateBatch(products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(products, 100).Error; err != nil {
+			return err
+		}
+		revisions := make([]*entity.ProductRevision, len(products))
+		for i, product := range products {
+			revisions[i] = entity.NewProductRevision(product)
+		}
+		return tx.CreateInBatches(revisions, 100).Error
+	})
 }
 
 func (p *Product) GetByID(id string) (*entity.Product, error) {
 	var product entity.Product
-	err := p.DB.Where("id = ?", id).First(&product).Error
+	err := p.DB.Preload("Prices").Preload("Tags").Preload("Images", orderImages).Where("id = ?", id).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
+// Update grava o produto somente se a versão no banco ainda for a mesma que foi lida
+// (UPDATE ... WHERE version = ?). Em caso de sucesso product.Version é incrementado.
+// As tags do produto são substituídas por product.Tags; os preços por moeda não são alterados.
 func (p *Product) Update(id string, product *entity.Product) error {
-	_, err := p.GetByID(product.ID.String())
+	return p.save(product, func(tx *gorm.DB) error {
+		return tx.Model(product).Association("Tags").Replace(product.Tags)
+	})
+}
+
+// Revert grava o produto como Update, mas também substitui os preços por moeda por
+// product.Prices. É usado para voltar o produto ao estado de uma revisão.
+func (p *Product) Revert(product *entity.Product) error {
+	return p.save(product, func(tx *gorm.DB) error {
+		if err := tx.Model(product).Association("Tags").Replace(product.Tags); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&entity.ProductPrice{}).Error; err != nil {
+			return err
+		}
+		if len(product.Prices) == 0 {
+			return nil
+		}
+		return tx.Create(&product.Prices).Error
+	})
+}
+
+// save grava os campos do produto condicionado à versão lida, executa fn na mesma
+// transação e registra a revisão da nova versão. product.Prices é recarregado do banco.
+func (p *Product) save(product *entity.Product, fn func(tx *gorm.DB) error) error {
+	current, err := p.GetByID(product.ID.String())
 	if err != nil {
 		return err
 	}
-	return p.DB.Save(product).Error
+
+	expected := product.Version
+	product.Version = expected + 1
+	var prices []entity.ProductPrice
+	err = p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(product).
+			Where("version = ?", expected).
+			Select("*").Omit("id", "created_at", "deleted_at", clause.Associations).
+			Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if err := tx.Where("product_id = ?", product.ID).Order("currency").Find(&prices).Error; err != nil {
+			return err
+		}
+		saved := *product
+		saved.Prices = prices
+		return saveRevisions(tx, entity.NewProductRevision(current), entity.NewProductRevision(&saved))
+	})
+	if err != nil {
+		product.Version = expected
+		return err
+	}
+	product.Prices = prices
+	return nil
 }
 
-func (p *Product) Delete(id string) error {
-	product, err := p.GetByID(id)
+// SetPrice cria ou substitui o preço explícito do produto na moeda de price.
+// Como o preço faz parte do produto, a versão é incrementada nas mesmas condições de Update.
+func (p *Product) SetPrice(product *entity.Product, price money.Money) error {
+	productPrice, err := entity.NewProductPrice(product.ID, price)
 	if err != nil {
 		return err
 	}
-	return p.DB.Delete(product).Error
+	if price.Currency == product.Price.Currency {
+		return entity.ErrBaseCurrency
+	}
+	return p.changePrices(product, func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"amount"}),
+		}).Create(productPrice).Error
+	})
 }
 
-func (p *Product) GetAll(page, limit int, sort string) ([]entity.Product, error) {
+// DeletePrice remove o preço explícito do produto na moeda informada
+func (p *Product) DeletePrice(product *entity.Product, currency string) error {
+	return p.changePrices(product, func(tx *gorm.DB) error {
+		result := tx.Where("product_id = ? AND currency = ?", product.ID, currency).Delete(&entity.ProductPrice{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// changePrices executa fn, incrementa a versão do produto e registra a revisão
+// numa única transação, depois atualiza product.Prices
+func (p *Product) changePrices(product *entity.Product, fn func(tx *gorm.DB) error) error {
+	expected := product.Version
+	now := time.Now()
+	var prices []entity.ProductPrice
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		result := tx.Model(&entity.Product{}).
+			Where("id = ? AND version = ?", product.ID, expected).
+			Updates(map[string]interface{}{"version": expected + 1, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		if err := tx.Where("product_id = ?", product.ID).Order("currency").Find(&prices).Error; err != nil {
+			return err
+		}
+		saved := *product
+		saved.Version, saved.UpdatedAt, saved.Prices = expected+1, now, prices
+		return saveRevisions(tx, entity.NewProductRevision(product), entity.NewProductRevision(&saved))
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Version = expected + 1
+	product.UpdatedAt = now
+	product.Prices = prices
+	return nil
+}
+
+// saveRevisions grava a revisão da nova versão. Produtos anteriores ao versionamento não têm
+// a revisão da versão lida, então ela é gravada antes, com a data da última alteração.
+func saveRevisions(tx *gorm.DB, previous, next *entity.ProductRevision) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(previous).Error; err != nil {
+		return err
+	}
+	return tx.Create(next).Error
+}
+
+// Revisions retorna as revisões do produto, da mais recente para a mais antiga
+func (p *Product) Revisions(productID string) ([]entity.ProductRevision, error) {
+	revisions := []entity.ProductRevision{}
+	if err := p.DB.Where("product_id = ?", productID).Order("version desc").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (p *Product) GetRevision(productID string, version int) (*entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+	if err := p.DB.Where("product_id = ? AND version = ?", productID, version).First(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetAsOf reconstrói o produto como ele estava em asOf, a partir da última revisão gravada
+// até lá. Produtos anteriores ao versionamento, ainda sem revisões, valem desde a última
+// alteração. Antes disso o retorno é gorm.ErrRecordNotFound.
+func (p *Product) GetAsOf(id string, asOf time.Time) (*entity.Product, error) {
+	product, err := p.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision entity.ProductRevision
+	err = p.DB.Where("product_id = ? AND created_at <= ?", id, asOf).Order("version desc").First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var revisions int64
+		if err := p.DB.Model(&entity.ProductRevision{}).Where("product_id = ?", id).Count(&revisions).Error; err != nil {
+			return nil, err
+		}
+		if revisions == 0 && !asOf.Before(product.UpdatedAt) {
+			return product, nil
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revision.Apply(product)
+	product.Version = revision.Version
+	product.UpdatedAt = revision.CreatedAt
+	return product, nil
+}
+
+// Delete move o produto para a lixeira (soft delete) somente se a versão no banco ainda for
+// version (UPDATE ... WHERE version = ?); ele some das consultas mas pode ser restaurado
+func (p *Product) Delete(id string, version int) error {
+	result := p.DB.Where("id = ? AND version = ?", id, version).Delete(&entity.Product{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Ou o produto não existe (ou já está na lixeira), ou foi alterado depois de lido
+		if _, err := p.GetByID(id); err != nil {
+			return err
+		}
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Restore tira o produto da lixeira
+func (p *Product) Restore(id string) error {
+	result := p.DB.Unscoped().Model(&entity.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge remove definitivamente um produto que já está na lixeira, junto com seus preços, tags, revisões e estoque
+func (p *Product) Purge(id string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&entity.Product{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return deleteProductData(tx, "product_id = ?", id)
+	})
+}
+
+// PurgeDeletedBefore remove definitivamente os produtos que estão na lixeira desde antes de cutoff
+// e retorna os IDs removidos
+func (p *Product) PurgeDeletedBefore(cutoff time.Time) ([]string, error) {
+	var purged []string
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&entity.Product{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &purged).Error
+		if err != nil || len(purged) == 0 {
+			return err
+		}
+		if err := deleteProductData(tx, "product_id IN ?", purged); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", purged).Delete(&entity.Product{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// saveProductChanges grava a nova versão de cada produto alterado a partir de outra entidade,
+// com a revisão dela, na transação tx. Os dados já devem ter sido gravados; aqui só a versão
+// e a data mudam, condicionadas à versão lida, como em save.
+func saveProductChanges(tx *gorm.DB, changes []ProductChange) error {
+	now := time.Now()
+	for _, change := range changes {
+		expected := change.Before.Version
+		result := tx.Unscoped().Model(&entity.Product{}).
+			Where("id = ? AND version = ?", change.Before.ID, expected).
+			Updates(map[string]interface{}{"version": expected + 1, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		change.After.Version, change.After.UpdatedAt = expected+1, now
+		if err := saveRevisions(tx, entity.NewProductRevision(change.Before), entity.NewProductRevision(change.After)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findProducts carrega os produtos selecionados por query, inclusive os que estão na lixeira,
+// com os preços e as tags, como GetByID
+func findProducts(tx *gorm.DB, query interface{}, args ...interface{}) ([]entity.Product, error) {
 	var products []entity.Product
+	err := tx.Unscoped().Preload("Prices").Preload("Tags").Where(query, args...).Order("id").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
 
+// orderImages mantém as imagens na ordem em que foram enviadas
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at").Order("id")
+}
+
+// deleteProductData apaga as linhas das tabelas que dependem dos produtos selecionados por query,
+// inclusive as variantes e o estoque delas. As imagens ficam para ProductImage.Orphans,
+// porque os arquivos delas precisam ser removidos antes.
+func deleteProductData(tx *gorm.DB, query string, args ...interface{}) error {
+	if err := tx.Table("product_tags").Where(query, args...).Delete(map[string]interface{}{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(query, args...).Delete(&entity.ProductPrice{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(query, args...).Delete(&entity.ProductRevision{}).Error; err != nil {
+		return err
+	}
+	variants := tx.Model(&entity.Variant{}).Select("id").Where(query, args...)
+	if err := deleteStockData(tx, "product_id IN (?)", variants); err != nil {
+		return err
+	}
+	if err := tx.Where(query, args...).Delete(&entity.Variant{}).Error; err != nil {
+		return err
+	}
+	return deleteStockData(tx, query, args...)
+}
+
+func (p *Product) GetAll(page, limit int, sort string) ([]entity.Product, error) {
 	// Sanitiza o valor de sort
 	if sort != "asc" && sort != "desc" {
 		sort = "asc"
 	}
+	return p.Search(ProductQuery{Page: page, Limit: limit, Sort: sort})
+}
 
-	// Prepara a query base
-	query := p.DB.Order("created_at " + sort)
+func (p *Product) Search(query ProductQuery) ([]entity.Product, error) {
+	var products []entity.Product
 
-	// Aplica paginação se necessário
-	if page > 0 && limit > 0 {
-		query = query.Offset((page - 1) * limit).Limit(limit)
+	if err := query.Validate(); err != nil {
+		return nil, err
 	}
 
 	// Executa a consulta
-	if err := query.Find(&products).Error; err != nil {
+	if err := query.apply(p.DB).Preload("Prices").Preload("Tags").Preload("Images", orderImages).Find(&products).Error; err != nil {
 		return nil, err
 	}
 
 	return products, nil
 }
+
+// Count retorna o total de produtos que atendem aos filtros, ignorando ordenação e paginação
+func (p *Product) Count(query ProductQuery) (int64, error) {
+	var total int64
+
+	if err := query.Validate(); err != nil {
+		return 0, err
+	}
+
+	if err := query.filter(p.DB.Model(&entity.Product{})).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
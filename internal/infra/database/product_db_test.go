@@ -2,25 +2,24 @@ package database
 
 import (
 	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func TestNewProduct(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file:memory:"), &gorm.Config{})
+	db, err := openTestDB("file:memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.Product{})
-	db.AutoMigrate(&entity.Product{})
-
-	product, err := entity.NewProduct("Test Product", 10.0)
+	product, err := entity.NewProduct("Test Product", money.New(1000, "BRL"))
 	assert.NoError(t, err)
 	productDB := NewProduct(db)
 	err = productDB.Create(product)
@@ -29,17 +28,15 @@ func TestNewProduct(t *testing.T) {
 }
 
 func TestAllProducts(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	db, err := openTestDB("file::memory:?cache=shared")
 	if err != nil {
 		t.Error(err)
 	}
 
 	// Limpa completamente a tabela antes de começar o teste
-	db.Migrator().DropTable(&entity.Product{})
-	db.AutoMigrate(&entity.Product{})
 
 	for i := 1; i < 24; i++ {
-		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), rand.Float64()*100)
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), money.New(rand.Int63n(10000)+1, "BRL"))
 		assert.NoError(t, err)
 		db.Create(product)
 	}
@@ -68,15 +65,12 @@ func TestAllProducts(t *testing.T) {
 }
 
 func TestGetByID(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	db, err := openTestDB("file::memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.Product{})
-	db.AutoMigrate(&entity.Product{})
-
-	product, err := entity.NewProduct("Test Product 1", 10.0)
+	product, err := entity.NewProduct("Test Product 1", money.New(1000, "BRL"))
 	assert.NoError(t, err)
 	db.Create(product)
 
@@ -87,15 +81,12 @@ func TestGetByID(t *testing.T) {
 }
 
 func TestUpdate(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	db, err := openTestDB("file::memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.Product{})
-	db.AutoMigrate(&entity.Product{})
-
-	product, err := entity.NewProduct("Test Product 2", 10.0)
+	product, err := entity.NewProduct("Test Product 2", money.New(1000, "BRL"))
 	assert.NoError(t, err)
 	db.Create(product)
 
@@ -112,22 +103,555 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestDelete(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	db, err := openTestDB("file::memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.Product{})
-	db.AutoMigrate(&entity.Product{})
-
-	product, err := entity.NewProduct("Test Product 3", 10.0)
+	product, err := entity.NewProduct("Test Product 3", money.New(1000, "BRL"))
 	assert.NoError(t, err)
 	db.Create(product)
 	productDB := NewProduct(db)
 
-	err = productDB.Delete(product.ID.String())
+	// Versão desatualizada não remove o produto
+	assert.ErrorIs(t, productDB.Delete(product.ID.String(), product.Version+1), ErrVersionConflict)
+	err = productDB.Delete(product.ID.String(), product.Version)
 	assert.NoError(t, err)
 	productFound, err := productDB.GetByID(product.ID.String())
 	assert.Error(t, err)
 	assert.Nil(t, productFound)
+	assert.ErrorIs(t, productDB.Delete(product.ID.String(), product.Version), gorm.ErrRecordNotFound)
+}
+
+func TestSearchProductsByOwner(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	ownerID := entitypkg.NewID()
+	for i := 1; i <= 3; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Owned %d", i), money.New(1000, "BRL"))
+		assert.NoError(t, err)
+		product.OwnerID = ownerID
+		db.Create(product)
+	}
+	other, _ := entity.NewProduct("Other", money.New(1000, "BRL"))
+	other.OwnerID = entitypkg.NewID()
+	db.Create(other)
+
+	productDB := NewProduct(db)
+	products, err := productDB.Search(ProductQuery{OwnerID: ownerID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+	for _, product := range products {
+		assert.Equal(t, ownerID, product.OwnerID)
+	}
+
+	products, err = productDB.Search(ProductQuery{OwnerID: ownerID.String(), Page: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+}
+
+func TestSearchProducts(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, p := range []struct {
+		name  string
+		price money.Money
+	}{
+		{"Notebook Pro", money.New(500000, "BRL")},
+		{"Notebook Air", money.New(350000, "BRL")},
+		{"Mouse", money.New(8000, "BRL")},
+		{"Mouse Pad 100%", money.New(3000, "BRL")},
+		{"Keyboard", money.New(25000, "BRL")},
+		{"Monitor", money.New(20000, "USD")},
+	} {
+		product, err := entity.NewProduct(p.name, p.price)
+		assert.NoError(t, err)
+		db.Create(product)
+	}
+
+	productDB := NewProduct(db)
+
+	// Busca por parte do nome, sem diferenciar maiúsculas
+	products, err := productDB.Search(ProductQuery{Name: "book", SortBy: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Notebook Air", products[0].Name)
+	assert.Equal(t, "Notebook Pro", products[1].Name)
+
+	// Busca por prefixo
+	products, err = productDB.Search(ProductQuery{NamePrefix: "mou", SortBy: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Mouse", products[0].Name)
+
+	// Curingas do LIKE são tratados como texto
+	products, err = productDB.Search(ProductQuery{Name: "%"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Mouse Pad 100%", products[0].Name)
+
+	// Faixa de preço, só entre produtos da mesma moeda
+	minPrice, maxPrice := money.New(5000, "BRL"), money.New(350000, "BRL")
+	products, err = productDB.Search(ProductQuery{MinPrice: &minPrice, MaxPrice: &maxPrice, SortBy: "price", Sort: "desc"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+	assert.Equal(t, "Notebook Air", products[0].Name)
+	assert.Equal(t, "Keyboard", products[1].Name)
+	assert.Equal(t, "Mouse", products[2].Name)
+
+	// Filtros combinados
+	products, err = productDB.Search(ProductQuery{Name: "notebook", MaxPrice: &maxPrice})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Notebook Air", products[0].Name)
+
+	usd := money.New(0, "USD")
+	products, err = productDB.Search(ProductQuery{MinPrice: &usd})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Monitor", products[0].Name)
+}
+
+func TestCountProducts(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	for i := 1; i <= 23; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), money.New(int64(i)*100, "BRL"))
+		assert.NoError(t, err)
+		db.Create(product)
+	}
+
+	productDB := NewProduct(db)
+
+	// Paginação não afeta o total
+	total, err := productDB.Count(ProductQuery{Page: 3, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(23), total)
+
+	minPrice := money.New(2000, "BRL")
+	total, err = productDB.Count(ProductQuery{MinPrice: &minPrice, Page: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+
+	total, err = productDB.Count(ProductQuery{Name: "does not exist"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestSearchProductsInvalidQuery(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	productDB := NewProduct(db)
+	_, err = productDB.Search(ProductQuery{SortBy: "owner_id; DROP TABLE products"})
+	assert.Equal(t, ErrInvalidSortField, err)
+
+	_, err = productDB.Search(ProductQuery{Sort: "up"})
+	assert.Equal(t, ErrInvalidSortOrder, err)
+
+	minPrice, maxPrice := money.New(1000, "BRL"), money.New(500, "BRL")
+	_, err = productDB.Search(ProductQuery{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.Equal(t, ErrInvalidRange, err)
+
+	maxPrice = money.New(500, "USD")
+	_, err = productDB.Search(ProductQuery{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestSearchProductsWithCursor(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	original := map[string]bool{}
+	for i := 1; i <= 23; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), money.New(1000, "BRL"))
+		assert.NoError(t, err)
+		db.Create(product)
+		original[product.ID.String()] = true
+	}
+
+	productDB := NewProduct(db)
+	for _, sort := range []string{"asc", "desc"} {
+		seen := map[string]bool{}
+		query := ProductQuery{Sort: sort, Page: 1, Limit: 5}
+		for pages := 0; ; pages++ {
+			assert.Less(t, pages, 10)
+			products, err := productDB.Search(query)
+			assert.NoError(t, err)
+			for _, product := range products {
+				assert.False(t, seen[product.ID.String()], "produto repetido no scan")
+				seen[product.ID.String()] = true
+			}
+			if len(products) < query.Limit {
+				break
+			}
+
+			// Inserções durante o scan não podem fazer o cursor pular nem repetir registros
+			inserted, _ := entity.NewProduct("Inserted", money.New(1000, "BRL"))
+			db.Create(inserted)
+
+			cursor, err := DecodeProductCursor(NewProductCursor(products[len(products)-1]).Encode())
+			assert.NoError(t, err)
+			query = ProductQuery{Sort: sort, Limit: 5, After: cursor}
+		}
+		for id := range original {
+			assert.True(t, seen[id], "produto pulado no scan")
+		}
+	}
+}
+
+func TestProductCursorInvalid(t *testing.T) {
+	_, err := DecodeProductCursor("not a cursor")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	cursor := &ProductCursor{CreatedAt: time.Now(), ID: "y"}
+	query := ProductQuery{SortBy: "price", After: cursor}
+	assert.Equal(t, ErrCursorSortField, query.Validate())
+}
+
+func TestSearchProductsByCreatedAt(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	base := time.Date(2025, time.January, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Day %d", i), money.New(1000, "BRL"))
+		assert.NoError(t, err)
+		product.CreatedAt = base.AddDate(0, 0, i)
+		db.Create(product)
+	}
+
+	productDB := NewProduct(db)
+	after, before := base.AddDate(0, 0, 1), base.AddDate(0, 0, 3)
+	products, err := productDB.Search(ProductQuery{CreatedAfter: &after, CreatedBefore: &before})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+	assert.Equal(t, "Day 1", products[0].Name)
+	assert.Equal(t, "Day 3", products[2].Name)
+	assert.True(t, products[0].CreatedAt.Equal(after))
+
+	products, err = productDB.Search(ProductQuery{SortBy: "created_at", Sort: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Day 4", products[0].Name)
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, err := entity.NewProduct("Trashed", money.New(1000, "BRL"))
+	assert.NoError(t, err)
+	db.Create(product)
+	kept, _ := entity.NewProduct("Kept", money.New(1000, "BRL"))
+	db.Create(kept)
+	productDB := NewProduct(db)
+
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+
+	// Some das consultas normais, mas continua no banco
+	_, err = productDB.GetByID(product.ID.String())
+	assert.Error(t, err)
+	products, err := productDB.Search(ProductQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	total, err := productDB.Count(ProductQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	trash, err := productDB.Search(ProductQuery{Deleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "Trashed", trash[0].Name)
+	assert.True(t, trash[0].DeletedAt.Valid)
+
+	assert.NoError(t, productDB.Restore(product.ID.String()))
+	restored, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+
+	// Só produtos na lixeira podem ser restaurados
+	assert.ErrorIs(t, productDB.Restore(kept.ID.String()), gorm.ErrRecordNotFound)
+}
+
+func TestPurge(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, _ := entity.NewProduct("Purged", money.New(1000, "BRL"))
+	db.Create(product)
+	productDB := NewProduct(db)
+
+	// Produto fora da lixeira não é removido
+	assert.ErrorIs(t, productDB.Purge(product.ID.String()), gorm.ErrRecordNotFound)
+
+	receipt, _ := entity.NewStockMovement(product.ID, entity.StockReceipt, 3, "", entitypkg.NewID())
+	_, err = NewStock(db).Adjust(receipt)
+	assert.NoError(t, err)
+
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+
+	trash, err := productDB.Search(ProductQuery{Deleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, trash, 0)
+
+	// O estoque do produto sai junto
+	var movements, levels int64
+	db.Model(&entity.StockMovement{}).Where("product_id = ?", product.ID).Count(&movements)
+	db.Model(&entity.StockLevel{}).Where("product_id = ?", product.ID).Count(&levels)
+	assert.Zero(t, movements)
+	assert.Zero(t, levels)
+	assert.ErrorIs(t, productDB.Restore(product.ID.String()), gorm.ErrRecordNotFound)
+}
+
+func TestPurgeDeletedBefore(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	old, _ := entity.NewProduct("Old", money.New(1000, "BRL"))
+	recent, _ := entity.NewProduct("Recent", money.New(1000, "BRL"))
+	active, _ := entity.NewProduct("Active", money.New(1000, "BRL"))
+	db.Create(old)
+	db.Create(recent)
+	db.Create(active)
+
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.SetPrice(old, money.New(200, "USD")))
+	assert.NoError(t, productDB.SetPrice(active, money.New(200, "USD")))
+	assert.NoError(t, productDB.Delete(old.ID.String(), old.Version))
+	assert.NoError(t, productDB.Delete(recent.ID.String(), recent.Version))
+	db.Unscoped().Model(&entity.Product{}).Where("id = ?", old.ID).Update("deleted_at", time.Now().AddDate(0, 0, -40))
+
+	purged, err := productDB.PurgeDeletedBefore(time.Now().AddDate(0, 0, -30))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{old.ID.String()}, purged)
+
+	trash, err := productDB.Search(ProductQuery{Deleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "Recent", trash[0].Name)
+
+	found, err := productDB.GetByID(active.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.Prices, 1)
+
+	var prices int64
+	db.Model(&entity.ProductPrice{}).Count(&prices)
+	assert.Equal(t, int64(1), prices)
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, _ := entity.NewProduct("Original", money.New(1000, "BRL"))
+	db.Create(product)
+	productDB := NewProduct(db)
+
+	// Dois editores leem a mesma versão
+	first, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	second, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+
+	first.Name = "First"
+	assert.NoError(t, productDB.Update(first.ID.String(), first))
+	assert.Equal(t, 2, first.Version)
+
+	second.Name = "Second"
+	assert.ErrorIs(t, productDB.Update(second.ID.String(), second), ErrVersionConflict)
+	assert.Equal(t, 1, second.Version)
+
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "First", found.Name)
+	assert.Equal(t, 2, found.Version)
+	assert.True(t, found.CreatedAt.Equal(product.CreatedAt))
+}
+
+func TestCreateBatch(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	productDB := NewProduct(db)
+
+	var products []*entity.Product
+	for i := 1; i <= 250; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), money.New(int64(i)*100, "BRL"))
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+	assert.NoError(t, productDB.CreateBatch(products))
+
+	var count int64
+	db.Model(&entity.Product{}).Count(&count)
+	assert.Equal(t, int64(250), count)
+
+	// Um ID repetido derruba o lote inteiro
+	first, _ := entity.NewProduct("First", money.New(1000, "BRL"))
+	duplicated, _ := entity.NewProduct("Duplicated", money.New(1000, "BRL"))
+	duplicated.ID = products[0].ID
+	assert.Error(t, productDB.CreateBatch([]*entity.Product{first, duplicated}))
+
+	db.Model(&entity.Product{}).Count(&count)
+	assert.Equal(t, int64(250), count)
+
+	assert.NoError(t, productDB.CreateBatch(nil))
+}
+
+func TestProductPrices(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, _ := entity.NewProduct("Notebook", money.New(500000, "BRL"))
+	other, _ := entity.NewProduct("Mouse", money.New(10000, "BRL"))
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.Create(product))
+	assert.NoError(t, productDB.Create(other))
+
+	assert.NoError(t, productDB.SetPrice(product, money.New(99900, "USD")))
+	assert.NoError(t, productDB.SetPrice(product, money.New(89900, "EUR")))
+	assert.Equal(t, 3, product.Version)
+	assert.Len(t, product.Prices, 2)
+
+	// Definir de novo a mesma moeda substitui o valor
+	assert.NoError(t, productDB.SetPrice(product, money.New(95000, "USD")))
+
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, found.Version)
+	price, ok := found.PriceIn("USD")
+	assert.True(t, ok)
+	assert.Equal(t, money.New(95000, "USD"), price)
+
+	products, err := productDB.Search(ProductQuery{Sort: "asc", SortBy: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Empty(t, products[0].Prices)
+	assert.Len(t, products[1].Prices, 2)
+
+	// Update não mexe nos preços
+	found.Name = "Notebook Pro"
+	assert.NoError(t, productDB.Update(found.ID.String(), found))
+	found, _ = productDB.GetByID(product.ID.String())
+	assert.Len(t, found.Prices, 2)
+
+	assert.ErrorIs(t, productDB.SetPrice(found, money.New(100, "BRL")), entity.ErrBaseCurrency)
+	assert.ErrorIs(t, productDB.SetPrice(found, money.New(0, "USD")), entity.ErrInvalidPrice)
+
+	stale := *found
+	assert.NoError(t, productDB.DeletePrice(found, "EUR"))
+	assert.Len(t, found.Prices, 1)
+	assert.ErrorIs(t, productDB.DeletePrice(found, "EUR"), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, productDB.SetPrice(&stale, money.New(100, "GBP")), ErrVersionConflict)
+
+	// A remoção definitiva leva os preços junto
+	assert.NoError(t, productDB.Delete(found.ID.String(), found.Version))
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+	var remaining int64
+	db.Model(&entity.ProductPrice{}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestSearchProductsByCategoryAndTag(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	categoryDB := NewCategory(db)
+	computers, _ := entity.NewCategory("Computadores", nil)
+	notebooks, _ := entity.NewCategory("Notebooks", &computers.ID)
+	assert.NoError(t, categoryDB.Create(computers))
+	assert.NoError(t, categoryDB.Create(notebooks))
+
+	tagDB := NewTag(db)
+	sale, _ := entity.NewTag("promoção")
+	gamer, _ := entity.NewTag("gamer")
+	assert.NoError(t, tagDB.Create(sale))
+	assert.NoError(t, tagDB.Create(gamer))
+
+	productDB := NewProduct(db)
+	desktop, _ := entity.NewProduct("Desktop", money.New(300000, "BRL"))
+	desktop.CategoryID = &computers.ID
+	desktop.Tags = []entity.Tag{*gamer}
+	notebook, _ := entity.NewProduct("Notebook", money.New(500000, "BRL"))
+	notebook.CategoryID = &notebooks.ID
+	notebook.Tags = []entity.Tag{*sale, *gamer}
+	mouse, _ := entity.NewProduct("Mouse", money.New(10000, "BRL"))
+	for _, product := range []*entity.Product{desktop, notebook, mouse} {
+		assert.NoError(t, productDB.Create(product))
+	}
+
+	products, err := productDB.Search(ProductQuery{CategoryIDs: []string{computers.ID.String()}})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Desktop", products[0].Name)
+
+	subtree, _ := categoryDB.SubtreeIDs(computers.ID.String())
+	products, err = productDB.Search(ProductQuery{CategoryIDs: subtree, SortBy: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	products, err = productDB.Search(ProductQuery{Tags: []string{"gamer"}, SortBy: "name"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Len(t, products[1].Tags, 2)
+
+	products, err = productDB.Search(ProductQuery{Tags: []string{"gamer", "promoção"}})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Notebook", products[0].Name)
+
+	total, err := productDB.Count(ProductQuery{Tags: []string{"gamer"}, CategoryIDs: subtree})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// Update substitui as tags
+	notebook.Tags = []entity.Tag{*sale}
+	assert.NoError(t, productDB.Update(notebook.ID.String(), notebook))
+	found, _ := productDB.GetByID(notebook.ID.String())
+	assert.Len(t, found.Tags, 1)
+	assert.Equal(t, "promoção", found.Tags[0].Name)
+
+	found.Tags = nil
+	found.CategoryID = nil
+	assert.NoError(t, productDB.Update(found.ID.String(), found))
+	found, _ = productDB.GetByID(notebook.ID.String())
+	assert.Empty(t, found.Tags)
+	assert.Nil(t, found.CategoryID)
+
+	// A remoção definitiva limpa a tabela de ligação
+	assert.NoError(t, productDB.Delete(desktop.ID.String(), desktop.Version))
+	assert.NoError(t, productDB.Purge(desktop.ID.String()))
+	var links int64
+	db.Table("product_tags").Count(&links)
+	assert.Equal(t, int64(0), links)
 }
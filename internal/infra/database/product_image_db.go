@@ -0,0 +1,60 @@
+package database
+
+import (
+	"apis/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+type ProductImage struct {
+	DB *gorm.DB
+}
+
+func NewProductImage(db *gorm.DB) *ProductImage {
+	return &ProductImage{DB: db}
+}
+
+func (p *ProductImage) Create(image *entity.ProductImage) error {
+	return p.DB.Create(image).Error
+}
+
+func (p *ProductImage) GetByID(id string) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	if err := p.DB.Where("id = ?", id).First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// GetByProduct retorna as imagens do produto na ordem em que foram enviadas
+func (p *ProductImage) GetByProduct(productID string) ([]entity.ProductImage, error) {
+	images := []entity.ProductImage{}
+	if err := orderImages(p.DB).Where("product_id = ?", productID).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (p *ProductImage) Delete(id string) error {
+	result := p.DB.Where("id = ?", id).Delete(&entity.ProductImage{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Orphans retorna até limit imagens cujo produto já foi removido definitivamente.
+// Purge não apaga as imagens porque os arquivos ficam fora do banco: quem chama
+// remove os arquivos e depois o registro, com Delete.
+func (p *ProductImage) Orphans(limit int) ([]entity.ProductImage, error) {
+	var images []entity.ProductImage
+	err := p.DB.Where("NOT EXISTS (SELECT 1 FROM products WHERE products.id = product_images.product_id)").
+		Limit(limit).Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
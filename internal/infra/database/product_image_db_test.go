@@ -0,0 +1,55 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestProductImages(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, _ := entity.NewProduct("Camera", money.New(99900, "BRL"))
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.Create(product))
+
+	imageDB := NewProductImage(db)
+	front, _ := entity.NewProductImage(product.ID, "image/jpeg", 2048, 800, 600, "front")
+	back, _ := entity.NewProductImage(product.ID, "image/png", 1024, 800, 600, "back")
+	back.CreatedAt = front.CreatedAt.Add(time.Second)
+	assert.NoError(t, imageDB.Create(back))
+	assert.NoError(t, imageDB.Create(front))
+
+	// As imagens vêm com o produto, na ordem de envio e com as URLs preenchidas
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.Images, 2)
+	assert.Equal(t, front.ID, found.Images[0].ID)
+	assert.Equal(t, front.URL, found.Images[0].URL)
+
+	image, err := imageDB.GetByID(back.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, back.ThumbnailURL, image.ThumbnailURL)
+
+	assert.NoError(t, imageDB.Delete(back.ID.String()))
+	assert.ErrorIs(t, imageDB.Delete(back.ID.String()), gorm.ErrRecordNotFound)
+
+	// Enquanto o produto está na lixeira as imagens continuam dele
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	orphans, err := imageDB.Orphans(10)
+	assert.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+	orphans, err = imageDB.Orphans(10)
+	assert.NoError(t, err)
+	assert.Len(t, orphans, 1)
+	assert.Equal(t, front.ID, orphans[0].ID)
+}
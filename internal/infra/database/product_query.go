@@ -0,0 +1,190 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSortField = errors.New("Invalid sort field")
+	ErrInvalidSortOrder = errors.New("Invalid sort order")
+	ErrInvalidRange     = errors.New("Invalid range")
+	ErrInvalidCursor    = errors.New("Invalid cursor")
+	ErrCursorSortField  = errors.New("Cursor pagination only supports sorting by created_at")
+)
+
+// ProductSortFields são as únicas colunas aceitas na ordenação
+var ProductSortFields = []string{"name", "price", "created_at"}
+
+// productSortColumns traduz os campos de ordenação para as colunas da tabela
+var productSortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price_amount",
+	"created_at": "created_at",
+}
+
+// ProductQuery descreve filtros, ordenação e paginação da listagem de produtos.
+// Campos zerados não filtram nada.
+type ProductQuery struct {
+	Name          string       // parte do nome, sem diferenciar maiúsculas
+	NamePrefix    string       // início do nome, sem diferenciar maiúsculas
+	MinPrice      *money.Money // só produtos com preço nesta moeda
+	MaxPrice      *money.Money
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	OwnerID       string
+	CategoryIDs   []string // produtos em qualquer uma destas categorias
+	Tags          []string // produtos com todas estas tags
+	SortBy        string   // name, price ou created_at (padrão)
+	Sort          string   // asc (padrão) ou desc
+	Page          int
+	Limit         int
+	After         *ProductCursor // modo cursor: retorna os registros depois desta posição
+	Deleted       bool           // lista apenas produtos na lixeira
+}
+
+// ProductCursor marca a posição do último registro lido na ordenação (created_at, id)
+type ProductCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// NewProductCursor cria o cursor que aponta para logo depois do produto informado
+func NewProductCursor(product entity.Product) *ProductCursor {
+	return &ProductCursor{CreatedAt: product.CreatedAt, ID: product.ID.String()}
+}
+
+// Encode gera o valor opaco entregue ao cliente
+func (c *ProductCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeProductCursor(value string) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c ProductCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func (q *ProductQuery) Validate() error {
+	if q.SortBy != "" && !isProductSortField(q.SortBy) {
+		return ErrInvalidSortField
+	}
+	if q.Sort != "" && q.Sort != "asc" && q.Sort != "desc" {
+		return ErrInvalidSortOrder
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil {
+		cmp, err := q.MinPrice.Cmp(*q.MaxPrice)
+		if err != nil {
+			return err
+		}
+		if cmp > 0 {
+			return ErrInvalidRange
+		}
+	}
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && q.CreatedAfter.After(*q.CreatedBefore) {
+		return ErrInvalidRange
+	}
+	if q.After != nil && q.SortBy != "" && q.SortBy != "created_at" {
+		return ErrCursorSortField
+	}
+	return nil
+}
+
+// filter aplica apenas as condições WHERE, sem ordenação nem paginação
+func (q *ProductQuery) filter(db *gorm.DB) *gorm.DB {
+	if q.Deleted {
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if q.Name != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", "%"+escapeLike(strings.ToLower(q.Name))+"%")
+	}
+	if q.NamePrefix != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", escapeLike(strings.ToLower(q.NamePrefix))+"%")
+	}
+	// Valores em moedas diferentes não são comparáveis, então a faixa de preço também filtra a moeda
+	if q.MinPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount >= ?", q.MinPrice.Currency, q.MinPrice.Amount)
+	}
+	if q.MaxPrice != nil {
+		db = db.Where("price_currency = ? AND price_amount <= ?", q.MaxPrice.Currency, q.MaxPrice.Amount)
+	}
+	if q.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		db = db.Where("created_at <= ?", *q.CreatedBefore)
+	}
+	if q.OwnerID != "" {
+		db = db.Where("owner_id = ?", q.OwnerID)
+	}
+	if len(q.CategoryIDs) > 0 {
+		db = db.Where("category_id IN ?", q.CategoryIDs)
+	}
+	for _, tag := range q.Tags {
+		db = db.Where("EXISTS (SELECT 1 FROM product_tags JOIN tags ON tags.id = product_tags.tag_id WHERE product_tags.product_id = products.id AND tags.name = ?)", tag)
+	}
+	return db
+}
+
+// apply aplica filtros, ordenação e paginação
+func (q *ProductQuery) apply(db *gorm.DB) *gorm.DB {
+	db = q.filter(db)
+
+	sortBy := q.SortBy
+	if !isProductSortField(sortBy) {
+		sortBy = "created_at"
+	}
+	sort := q.Sort
+	if sort != "asc" && sort != "desc" {
+		sort = "asc"
+	}
+	// id desempata registros com o mesmo valor e deixa a paginação estável
+	db = db.Order(productSortColumns[sortBy] + " " + sort).Order("id " + sort)
+
+	// Keyset: continua a partir do último (created_at, id) lido, sem OFFSET
+	if q.After != nil {
+		op := ">"
+		if sort == "desc" {
+			op = "<"
+		}
+		db = db.Where("(created_at "+op+" ? OR (created_at = ? AND id "+op+" ?))", q.After.CreatedAt, q.After.CreatedAt, q.After.ID)
+		if q.Limit > 0 {
+			db = db.Limit(q.Limit)
+		}
+		return db
+	}
+
+	if q.Page > 0 && q.Limit > 0 {
+		db = db.Offset((q.Page - 1) * q.Limit).Limit(q.Limit)
+	}
+	return db
+}
+
+func isProductSortField(field string) bool {
+	for _, f := range ProductSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike escapa os curingas do LIKE com '!'. A barra invertida não serve como escape
+// em todos os bancos: no MySQL ela já é escape dentro da própria string SQL.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
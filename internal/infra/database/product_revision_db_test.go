@@ -0,0 +1,99 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestProductRevisions(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	productDB := NewProduct(db)
+	product, _ := entity.NewProduct("Mouse", money.New(5000, "BRL"))
+	assert.NoError(t, productDB.Create(product))
+	created := time.Now()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, productDB.SetPrice(product, money.New(1000, "USD")))
+	product.Name = "Mouse Gamer"
+	product.Price = money.New(7000, "BRL")
+	assert.NoError(t, productDB.Update(product.ID.String(), product))
+	assert.Equal(t, 3, product.Version)
+
+	revisions, err := productDB.Revisions(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 3)
+	assert.Equal(t, 3, revisions[0].Version)
+	assert.Equal(t, "Mouse Gamer", revisions[0].Snapshot.Name)
+	assert.Equal(t, []money.Money{money.New(1000, "USD")}, revisions[0].Snapshot.Prices)
+	assert.Empty(t, revisions[2].Snapshot.Prices)
+
+	// Leitura no passado: a primeira versão, sem o preço em dólar
+	old, err := productDB.GetAsOf(product.ID.String(), created)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, old.Version)
+	assert.Equal(t, "Mouse", old.Name)
+	assert.Equal(t, money.New(5000, "BRL"), old.Price)
+	assert.Empty(t, old.Prices)
+
+	_, err = productDB.GetAsOf(product.ID.String(), created.Add(-time.Hour))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Reverter grava uma nova versão com os dados da revisão, inclusive os preços
+	revision, err := productDB.GetRevision(product.ID.String(), 1)
+	assert.NoError(t, err)
+	revision.Apply(product)
+	assert.NoError(t, productDB.Revert(product))
+	assert.Equal(t, 4, product.Version)
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Mouse", found.Name)
+	assert.Equal(t, money.New(5000, "BRL"), found.Price)
+	assert.Empty(t, found.Prices)
+
+	_, err = productDB.GetRevision(product.ID.String(), 9)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+	revisions, err = productDB.Revisions(product.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestProductRevisionsOfLegacyProduct(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Produto gravado antes do versionamento, sem revisões
+	product, _ := entity.NewProduct("Teclado", money.New(15000, "BRL"))
+	product.UpdatedAt = time.Now().Add(-time.Hour)
+	assert.NoError(t, db.Create(product).Error)
+
+	productDB := NewProduct(db)
+	current, err := productDB.GetAsOf(product.ID.String(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "Teclado", current.Name)
+
+	// A primeira alteração grava também a revisão da versão anterior
+	product.Name = "Teclado Mecânico"
+	assert.NoError(t, productDB.Update(product.ID.String(), product))
+	revisions, err := productDB.Revisions(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, "Teclado", revisions[1].Snapshot.Name)
+
+	old, err := productDB.GetAsOf(product.ID.String(), time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, "Teclado", old.Name)
+}
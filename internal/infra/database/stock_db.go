@@ -0,0 +1,277 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock    = errors.New("Insufficient stock")
+	ErrReservationNotActive = errors.New("Reservation is no longer active")
+)
+
+// Stock guarda saldo, livro de lançamentos e reservas de estoque.
+// Toda alteração de saldo é um UPDATE condicional (... WHERE on_hand - reserved >= ?) cujo
+// número de linhas afetadas decide se a operação cabe no estoque. Como o SQLite serializa
+// escritas, duas requisições concorrentes nunca vendem ou reservam a mesma unidade.
+type Stock struct {
+	DB *gorm.DB
+}
+
+func NewStock(db *gorm.DB) *Stock {
+	return &Stock{DB: db}
+}
+
+// Level retorna o saldo do produto, liberando antes as reservas dele que já expiraram.
+// Produtos sem nenhum lançamento têm saldo zero.
+func (s *Stock) Level(productID string) (*entity.StockLevel, error) {
+	var level entity.StockLevel
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := releaseExpiredReservations(tx, time.Now(), productID); err != nil {
+			return err
+		}
+		result := tx.Where("product_id = ?", productID).Limit(1).Find(&level)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			id, err := entitypkg.ParseID(productID)
+			if err != nil {
+				return err
+			}
+			level = entity.StockLevel{ProductID: id}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// Adjust grava o lançamento e aplica a variação ao saldo na mesma transação.
+// Saídas, inclusive correções negativas, só usam o saldo disponível (o que não está reservado).
+func (s *Stock) Adjust(movement *entity.StockMovement) (*entity.StockLevel, error) {
+	var level entity.StockLevel
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if _, err := releaseExpiredReservations(tx, now, movement.ProductID.String()); err != nil {
+			return err
+		}
+		if err := ensureStockLevel(tx, movement.ProductID); err != nil {
+			return err
+		}
+
+		update := tx.Model(&entity.StockLevel{}).Where("product_id = ?", movement.ProductID)
+		if movement.Quantity < 0 {
+			update = update.Where("on_hand - reserved >= ?", -movement.Quantity)
+		}
+		result := update.Updates(map[string]interface{}{
+			"on_hand":    gorm.Expr("on_hand + ?", movement.Quantity),
+			"updated_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+
+		if err := tx.Create(movement).Error; err != nil {
+			return err
+		}
+		return tx.Where("product_id = ?", movement.ProductID).First(&level).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// Movements lista os lançamentos do produto, do mais recente para o mais antigo
+func (s *Stock) Movements(productID string, page, limit int) ([]entity.StockMovement, error) {
+	var movements []entity.StockMovement
+	query := s.DB.Where("product_id = ?", productID).Order("created_at desc").Order("id desc")
+	if page > 0 && limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	if err := query.Find(&movements).Error; err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// Reserve segura reservation.Quantity unidades se houver saldo disponível
+func (s *Stock) Reserve(reservation *entity.StockReservation) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if _, err := releaseExpiredReservations(tx, now, reservation.ProductID.String()); err != nil {
+			return err
+		}
+		if err := ensureStockLevel(tx, reservation.ProductID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.StockLevel{}).
+			Where("product_id = ? AND on_hand - reserved >= ?", reservation.ProductID, reservation.Quantity).
+			Updates(map[string]interface{}{
+				"reserved":   gorm.Expr("reserved + ?", reservation.Quantity),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+		return tx.Create(reservation).Error
+	})
+}
+
+func (s *Stock) GetReservation(id string) (*entity.StockReservation, error) {
+	var reservation entity.StockReservation
+	if err := s.DB.Where("id = ?", id).First(&reservation).Error; err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Release devolve ao estoque as unidades de uma reserva ativa
+func (s *Stock) Release(id string) (*entity.StockReservation, error) {
+	var reservation entity.StockReservation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&reservation).Error; err != nil {
+			return err
+		}
+		return finishReservation(tx, &reservation, entity.ReservationReleased, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Commit confirma uma reserva ativa: as unidades saem do estoque e viram um lançamento de venda
+func (s *Stock) Commit(id string, createdBy entitypkg.ID) (*entity.StockMovement, error) {
+	var movement *entity.StockMovement
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var reservation entity.StockReservation
+		if err := tx.Where("id = ?", id).First(&reservation).Error; err != nil {
+			return err
+		}
+		// Uma reserva vencida não pode mais ser confirmada, mesmo que ainda não tenha sido liberada
+		now := time.Now()
+		if !reservation.IsActive(now) {
+			return ErrReservationNotActive
+		}
+		if err := finishReservation(tx, &reservation, entity.ReservationCommitted, now); err != nil {
+			return err
+		}
+
+		var err error
+		movement, err = entity.NewStockMovement(reservation.ProductID, entity.StockSale, reservation.Quantity, "", createdBy)
+		if err != nil {
+			return err
+		}
+		movement.ReservationID = &reservation.ID
+
+		result := tx.Model(&entity.StockLevel{}).
+			Where("product_id = ? AND on_hand >= ?", reservation.ProductID, reservation.Quantity).
+			Updates(map[string]interface{}{
+				"on_hand":    gorm.Expr("on_hand - ?", reservation.Quantity),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// ReleaseExpired libera todas as reservas vencidas até now e retorna quantas foram liberadas
+func (s *Stock) ReleaseExpired(now time.Time) (int64, error) {
+	var released int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		released, err = releaseExpiredReservations(tx, now, "")
+		return err
+	})
+	return released, err
+}
+
+// releaseExpiredReservations marca como expiradas as reservas ativas vencidas
+// (de um produto ou, com productID vazio, de todos) e devolve as unidades ao estoque
+func releaseExpiredReservations(tx *gorm.DB, now time.Time, productID string) (int64, error) {
+	var expired []entity.StockReservation
+	query := tx.Where("status = ? AND expires_at <= ?", entity.ReservationActive, now)
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if err := query.Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	var released int64
+	for i := range expired {
+		err := finishReservation(tx, &expired[i], entity.ReservationExpired, now)
+		if errors.Is(err, ErrReservationNotActive) {
+			continue
+		}
+		if err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+// finishReservation tira a reserva do estado ativo e desconta a quantidade de reserved.
+// O UPDATE condicional em status garante que a mesma reserva não é encerrada duas vezes.
+func finishReservation(tx *gorm.DB, reservation *entity.StockReservation, status entity.ReservationStatus, now time.Time) error {
+	result := tx.Model(&entity.StockReservation{}).
+		Where("id = ? AND status = ?", reservation.ID, entity.ReservationActive).
+		Updates(map[string]interface{}{"status": status, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotActive
+	}
+	reservation.Status = status
+	reservation.UpdatedAt = now
+
+	return tx.Model(&entity.StockLevel{}).
+		Where("product_id = ?", reservation.ProductID).
+		Updates(map[string]interface{}{
+			"reserved":   gorm.Expr("reserved - ?", reservation.Quantity),
+			"updated_at": now,
+		}).Error
+}
+
+// ensureStockLevel cria o saldo zerado do produto se ele ainda não existir
+func ensureStockLevel(tx *gorm.DB, productID entitypkg.ID) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.StockLevel{ProductID: productID, UpdatedAt: time.Now()}).Error
+}
+
+// deleteStockData apaga saldo, lançamentos e reservas dos itens selecionados por query
+func deleteStockData(tx *gorm.DB, query string, args ...interface{}) error {
+	for _, model := range []interface{}{&entity.StockMovement{}, &entity.StockReservation{}, &entity.StockLevel{}} {
+		if err := tx.Where(query, args...).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
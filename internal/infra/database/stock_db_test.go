@@ -0,0 +1,204 @@
+package database
+
+import (
+	"apis/db"
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openStockDB usa um arquivo com as mesmas opções de db.Connect, para que as
+// conexões concorrentes do pool disputem o banco como em produção
+func openStockDB(t *testing.T) *gorm.DB {
+	gormDB, err := gorm.Open(sqlite.Open(db.SQLiteDSN(filepath.Join(t.TempDir(), "stock.sqlite"), "5000")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, resetTestDB(gormDB))
+	return gormDB
+}
+
+func TestStockAdjust(t *testing.T) {
+	stockDB := NewStock(openStockDB(t))
+	productID, userID := entitypkg.NewID(), entitypkg.NewID()
+
+	level, err := stockDB.Level(productID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, level.OnHand)
+
+	receipt, _ := entity.NewStockMovement(productID, entity.StockReceipt, 10, "NF 123", userID)
+	level, err = stockDB.Adjust(receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, level.OnHand)
+
+	sale, _ := entity.NewStockMovement(productID, entity.StockSale, 4, "", userID)
+	level, err = stockDB.Adjust(sale)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, level.OnHand)
+
+	tooMuch, _ := entity.NewStockMovement(productID, entity.StockSale, 7, "", userID)
+	_, err = stockDB.Adjust(tooMuch)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	correction, _ := entity.NewStockMovement(productID, entity.StockCorrection, -7, "inventário", userID)
+	_, err = stockDB.Adjust(correction)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	correction, _ = entity.NewStockMovement(productID, entity.StockCorrection, -1, "inventário", userID)
+	level, err = stockDB.Adjust(correction)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, level.OnHand)
+
+	// Os lançamentos recusados não entram no livro
+	movements, err := stockDB.Movements(productID.String(), 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 3)
+	total := 0
+	for _, m := range movements {
+		total += m.Quantity
+	}
+	assert.Equal(t, level.OnHand, total)
+
+	movements, err = stockDB.Movements(productID.String(), 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+}
+
+func TestStockReservations(t *testing.T) {
+	stockDB := NewStock(openStockDB(t))
+	productID, userID := entitypkg.NewID(), entitypkg.NewID()
+
+	receipt, _ := entity.NewStockMovement(productID, entity.StockReceipt, 5, "", userID)
+	_, err := stockDB.Adjust(receipt)
+	assert.NoError(t, err)
+
+	first, _ := entity.NewStockReservation(productID, 3, time.Minute, userID)
+	assert.NoError(t, stockDB.Reserve(first))
+	second, _ := entity.NewStockReservation(productID, 3, time.Minute, userID)
+	assert.ErrorIs(t, stockDB.Reserve(second), ErrInsufficientStock)
+
+	level, _ := stockDB.Level(productID.String())
+	assert.Equal(t, 5, level.OnHand)
+	assert.Equal(t, 3, level.Reserved)
+	assert.Equal(t, 2, level.Available())
+
+	// O estoque reservado não pode ser vendido por fora da reserva
+	sale, _ := entity.NewStockMovement(productID, entity.StockSale, 3, "", userID)
+	_, err = stockDB.Adjust(sale)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	movement, err := stockDB.Commit(first.ID.String(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, -3, movement.Quantity)
+	assert.Equal(t, first.ID, *movement.ReservationID)
+	_, err = stockDB.Commit(first.ID.String(), userID)
+	assert.ErrorIs(t, err, ErrReservationNotActive)
+
+	level, _ = stockDB.Level(productID.String())
+	assert.Equal(t, 2, level.OnHand)
+	assert.Equal(t, 0, level.Reserved)
+
+	third, _ := entity.NewStockReservation(productID, 2, time.Minute, userID)
+	assert.NoError(t, stockDB.Reserve(third))
+	released, err := stockDB.Release(third.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ReservationReleased, released.Status)
+	_, err = stockDB.Release(third.ID.String())
+	assert.ErrorIs(t, err, ErrReservationNotActive)
+
+	level, _ = stockDB.Level(productID.String())
+	assert.Equal(t, 2, level.Available())
+}
+
+func TestStockReservationExpiry(t *testing.T) {
+	stockDB := NewStock(openStockDB(t))
+	productID, userID := entitypkg.NewID(), entitypkg.NewID()
+
+	receipt, _ := entity.NewStockMovement(productID, entity.StockReceipt, 4, "", userID)
+	_, err := stockDB.Adjust(receipt)
+	assert.NoError(t, err)
+
+	reservation, _ := entity.NewStockReservation(productID, 4, time.Minute, userID)
+	assert.NoError(t, stockDB.Reserve(reservation))
+
+	// Uma reserva vencida não pode ser confirmada
+	stockDB.DB.Model(&entity.StockReservation{}).Where("id = ?", reservation.ID).Update("expires_at", time.Now().Add(-time.Second))
+	_, err = stockDB.Commit(reservation.ID.String(), userID)
+	assert.ErrorIs(t, err, ErrReservationNotActive)
+
+	released, err := stockDB.ReleaseExpired(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), released)
+
+	found, err := stockDB.GetReservation(reservation.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ReservationExpired, found.Status)
+
+	level, _ := stockDB.Level(productID.String())
+	assert.Equal(t, 4, level.Available())
+
+	// Reserve também libera as reservas vencidas do produto antes de verificar o saldo
+	other, _ := entity.NewStockReservation(productID, 4, time.Minute, userID)
+	assert.NoError(t, stockDB.Reserve(other))
+	stockDB.DB.Model(&entity.StockReservation{}).Where("id = ?", other.ID).Update("expires_at", time.Now().Add(-time.Second))
+	again, _ := entity.NewStockReservation(productID, 4, time.Minute, userID)
+	assert.NoError(t, stockDB.Reserve(again))
+}
+
+func TestStockConcurrentSalesNeverOversell(t *testing.T) {
+	stockDB := NewStock(openStockDB(t))
+	productID, userID := entitypkg.NewID(), entitypkg.NewID()
+
+	receipt, _ := entity.NewStockMovement(productID, entity.StockReceipt, 10, "", userID)
+	_, err := stockDB.Adjust(receipt)
+	assert.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		sold      int
+		reserved  int
+		unexpects []error
+	)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				sale, _ := entity.NewStockMovement(productID, entity.StockSale, 1, "", userID)
+				_, err = stockDB.Adjust(sale)
+			} else {
+				reservation, _ := entity.NewStockReservation(productID, 1, time.Minute, userID)
+				err = stockDB.Reserve(reservation)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && i%2 == 0:
+				sold++
+			case err == nil:
+				reserved++
+			case err != ErrInsufficientStock:
+				unexpects = append(unexpects, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Empty(t, unexpects)
+	assert.Equal(t, 10, sold+reserved)
+
+	level, err := stockDB.Level(productID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 10-sold, level.OnHand)
+	assert.Equal(t, reserved, level.Reserved)
+	assert.Equal(t, 0, level.Available())
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrTagExists = errors.New("Tag already exists")
+
+type Tag struct {
+	DB *gorm.DB
+}
+
+func NewTag(db *gorm.DB) *Tag {
+	return &Tag{DB: db}
+}
+
+func (t *Tag) Create(tag *entity.Tag) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkTagName(tx, tag); err != nil {
+			return err
+		}
+		return tx.Create(tag).Error
+	})
+}
+
+func (t *Tag) GetByID(id string) (*entity.Tag, error) {
+	var tag entity.Tag
+	if err := t.DB.Where("id = ?", id).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByNames retorna as tags com os nomes informados; nomes inexistentes são ignorados
+func (t *Tag) GetByNames(names []string) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	if len(names) == 0 {
+		return tags, nil
+	}
+	if err := t.DB.Where("name IN ?", names).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetAll retorna todas as tags, em ordem alfabética
+func (t *Tag) GetAll() ([]entity.Tag, error) {
+	var tags []entity.Tag
+	if err := t.DB.Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Update renomeia a tag; os produtos continuam ligados a ela
+func (t *Tag) Update(tag *entity.Tag) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewTag(tx).GetByID(tag.ID.String()); err != nil {
+			return err
+		}
+		if err := checkTagName(tx, tag); err != nil {
+			return err
+		}
+		return tx.Model(tag).Update("name", tag.Name).Error
+	})
+}
+
+// Delete remove a tag e a tira de todos os produtos, que ganham uma nova versão com revisão
+// e são retornados antes e depois da alteração
+func (t *Tag) Delete(id string) ([]ProductChange, error) {
+	var changes []ProductChange
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		tag, err := NewTag(tx).GetByID(id)
+		if err != nil {
+			return err
+		}
+		products, err := findProducts(tx, "id IN (?)", tx.Table("product_tags").Select("product_id").Where("tag_id = ?", id))
+		if err != nil {
+			return err
+		}
+		if err := tx.Table("product_tags").Where("tag_id = ?", id).Delete(map[string]interface{}{}).Error; err != nil {
+			return err
+		}
+		changes = make([]ProductChange, len(products))
+		for i := range products {
+			after := products[i]
+			after.Tags = make([]entity.Tag, 0, len(products[i].Tags))
+			for _, productTag := range products[i].Tags {
+				if productTag.ID != tag.ID {
+					after.Tags = append(after.Tags, productTag)
+				}
+			}
+			changes[i] = ProductChange{Before: &products[i], After: &after}
+		}
+		if err := saveProductChanges(tx, changes); err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// checkTagName impede duas tags com o mesmo nome
+func checkTagName(tx *gorm.DB, tag *entity.Tag) error {
+	var count int64
+	err := tx.Model(&entity.Tag{}).Where("name = ? AND id <> ?", tag.Name, tag.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTagExists
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	tagDB := NewTag(db)
+	sale, _ := entity.NewTag("Promoção")
+	gamer, _ := entity.NewTag("gamer")
+	assert.NoError(t, tagDB.Create(sale))
+	assert.NoError(t, tagDB.Create(gamer))
+
+	duplicate, _ := entity.NewTag("PROMOÇÃO ")
+	duplicate.Name = sale.Name
+	assert.ErrorIs(t, tagDB.Create(duplicate), ErrTagExists)
+
+	tags, err := tagDB.GetByNames([]string{"promoção", "inexistente"})
+	assert.NoError(t, err)
+	assert.Len(t, tags, 1)
+	assert.Equal(t, sale.ID, tags[0].ID)
+
+	gamer.Name = sale.Name
+	assert.ErrorIs(t, tagDB.Update(gamer), ErrTagExists)
+	gamer.Name = "games"
+	assert.NoError(t, tagDB.Update(gamer))
+
+	all, err := tagDB.GetAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"games", "promoção"}, []string{all[0].Name, all[1].Name})
+
+	// Remover a tag a tira dos produtos
+	product, _ := entity.NewProduct("Mouse", money.New(10000, "BRL"))
+	product.Tags = []entity.Tag{*sale, *gamer}
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.Create(product))
+
+	changes, err := tagDB.Delete(sale.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Len(t, changes[0].Before.Tags, 2)
+	assert.Len(t, changes[0].After.Tags, 1)
+	found, err := productDB.GetByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.Tags, 1)
+	assert.Equal(t, "games", found.Tags[0].Name)
+	assert.Equal(t, 2, found.Version)
+	revision, err := productDB.GetRevision(product.ID.String(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, revision.Snapshot.Tags, 1)
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"apis/configs"
+	"apis/db"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// openTestDB abre o banco de um teste com a mesma fábrica de dialetos do servidor e cria
+// o esquema pelas migrações versionadas, como o migrate up, para que os testes exercitem
+// os arquivos SQL de cada dialeto. Por padrão é o SQLite informado; com TEST_DB_DSN
+// (postgres://, mysql:// ou sqlite://) os testes rodam contra esse banco. Em ambos os casos
+// as tabelas existentes são apagadas antes, e cada teste começa com o banco vazio.
+func openTestDB(sqliteDSN string) (*gorm.DB, error) {
+	driver, dsn := configs.DriverSQLite, sqliteDSN
+	if raw := os.Getenv("TEST_DB_DSN"); raw != "" {
+		var err error
+		if driver, dsn, err = configs.ParseDSN(raw); err != nil {
+			return nil, err
+		}
+	}
+	dialector, err := db.Dialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	gormDB, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := resetTestDB(gormDB); err != nil {
+		return nil, err
+	}
+	return gormDB, nil
+}
+
+// resetTestDB apaga todas as tabelas e aplica as migrações
+func resetTestDB(gormDB *gorm.DB) error {
+	tables, err := gormDB.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := gormDB.Migrator().DropTable(table); err != nil {
+			return err
+		}
+	}
+	return db.Migrate(gormDB, db.Options{DefaultCurrency: "BRL"})
+}
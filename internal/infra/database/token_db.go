@@ -0,0 +1,78 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Token struct {
+	DB *gorm.DB
+}
+
+func NewToken(db *gorm.DB) *Token {
+	return &Token{DB: db}
+}
+
+func (t *Token) CreateRefreshToken(token *entity.RefreshToken) error {
+	return t.DB.Create(token).Error
+}
+
+func (t *Token) GetRefreshTokenByHash(hash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := t.DB.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken revoga o token antigo e grava o novo na mesma transação.
+// O UPDATE condicional garante que duas requisições concorrentes não consigam
+// rotacionar o mesmo token.
+func (t *Token) RotateRefreshToken(old, new *entity.RefreshToken) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&entity.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return entity.ErrRefreshTokenRevoked
+		}
+		old.RevokedAt = &now
+		return tx.Create(new).Error
+	})
+}
+
+func (t *Token) RevokeRefreshToken(id string) error {
+	return t.DB.Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (t *Token) RevokeAllRefreshTokens(userID string) error {
+	return t.DB.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAccessToken adiciona o jti na denylist e aproveita para limpar entradas já expiradas
+func (t *Token) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if err := t.DB.Where("expires_at < ?", time.Now()).Delete(&entity.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return t.DB.Save(&entity.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (t *Token) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := t.DB.Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
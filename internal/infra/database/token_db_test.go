@@ -0,0 +1,108 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRefreshToken(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	token, plain, err := entity.NewRefreshToken(entitypkg.NewID(), time.Hour)
+	assert.NoError(t, err)
+	tokenDB := NewToken(db)
+	assert.NoError(t, tokenDB.CreateRefreshToken(token))
+
+	tokenFound, err := tokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(plain))
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, tokenFound.ID)
+	assert.Equal(t, token.UserID, tokenFound.UserID)
+	assert.Nil(t, tokenFound.RevokedAt)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	userID := entitypkg.NewID()
+	old, oldPlain, _ := entity.NewRefreshToken(userID, time.Hour)
+	tokenDB := NewToken(db)
+	assert.NoError(t, tokenDB.CreateRefreshToken(old))
+
+	new, newPlain, _ := entity.NewRefreshToken(userID, time.Hour)
+	assert.NoError(t, tokenDB.RotateRefreshToken(old, new))
+
+	oldFound, err := tokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(oldPlain))
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ErrRefreshTokenRevoked, oldFound.Validate())
+
+	newFound, err := tokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(newPlain))
+	assert.NoError(t, err)
+	assert.NoError(t, newFound.Validate())
+
+	// Um token já rotacionado não pode ser rotacionado de novo
+	another, _, _ := entity.NewRefreshToken(userID, time.Hour)
+	assert.Equal(t, entity.ErrRefreshTokenRevoked, tokenDB.RotateRefreshToken(oldFound, another))
+}
+
+func TestRevokeAllRefreshTokens(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	userID := entitypkg.NewID()
+	tokenDB := NewToken(db)
+	var plains []string
+	for i := 0; i < 3; i++ {
+		token, plain, _ := entity.NewRefreshToken(userID, time.Hour)
+		assert.NoError(t, tokenDB.CreateRefreshToken(token))
+		plains = append(plains, plain)
+	}
+	other, otherPlain, _ := entity.NewRefreshToken(entitypkg.NewID(), time.Hour)
+	assert.NoError(t, tokenDB.CreateRefreshToken(other))
+
+	assert.NoError(t, tokenDB.RevokeAllRefreshTokens(userID.String()))
+
+	for _, plain := range plains {
+		token, err := tokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(plain))
+		assert.NoError(t, err)
+		assert.NotNil(t, token.RevokedAt)
+	}
+	otherFound, err := tokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(otherPlain))
+	assert.NoError(t, err)
+	assert.Nil(t, otherFound.RevokedAt)
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	tokenDB := NewToken(db)
+	revoked, err := tokenDB.IsAccessTokenRevoked("jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, tokenDB.RevokeAccessToken("jti-1", time.Now().Add(time.Hour)))
+	revoked, err = tokenDB.IsAccessTokenRevoked("jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Entradas expiradas são removidas na próxima revogação
+	assert.NoError(t, tokenDB.RevokeAccessToken("jti-2", time.Now().Add(-time.Minute)))
+	assert.NoError(t, tokenDB.RevokeAccessToken("jti-3", time.Now().Add(time.Hour)))
+	revoked, err = tokenDB.IsAccessTokenRevoked("jti-2")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
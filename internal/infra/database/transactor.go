@@ -0,0 +1,46 @@
+package database
+
+import "gorm.io/gorm"
+
+// Repositories reúne os repositórios ligados a uma mesma conexão ou transação
+type Repositories struct {
+	Products   ProductInterface
+	Categories CategoryInterface
+	Tags       TagInterface
+	Variants   VariantInterface
+	Images     ProductImageInterface
+	Stock      StockInterface
+	Users      UserInterface
+	Audit      AuditInterface
+}
+
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Products:   NewProduct(db),
+		Categories: NewCategory(db),
+		Tags:       NewTag(db),
+		Variants:   NewVariant(db),
+		Images:     NewProductImage(db),
+		Stock:      NewStock(db),
+		Users:      NewUser(db),
+		Audit:      NewAudit(db),
+	}
+}
+
+// Transactor executa várias operações de repositórios numa única transação, como uma
+// alteração e a entrada de auditoria dela
+type Transactor struct {
+	DB *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{DB: db}
+}
+
+// Transaction executa fn com repositórios ligados a uma transação.
+// Se fn retornar erro, tudo o que foi feito por ele é desfeito.
+func (t *Transactor) Transaction(fn func(tx *Repositories) error) error {
+	return t.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/money"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTransactor(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	productDB := NewProduct(db)
+	auditDB := NewAudit(db)
+	transactor := NewTransactor(db)
+
+	existing, _ := entity.NewProduct("Existing", money.New(1000, "BRL"))
+	assert.NoError(t, productDB.Create(existing))
+
+	// Um erro no meio desfaz as operações anteriores, inclusive a entrada de auditoria
+	created, _ := entity.NewProduct("Created", money.New(2000, "BRL"))
+	err = transactor.Transaction(func(tx *Repositories) error {
+		if err := tx.Products.Create(created); err != nil {
+			return err
+		}
+		entry, _ := entity.NewAuditEntry(nil, entity.AuditCreate, entity.AuditProduct, created.ID, nil, created.AuditFields())
+		if err := tx.Audit.Record(entry); err != nil {
+			return err
+		}
+		if err := tx.Products.Delete(existing.ID.String(), existing.Version); err != nil {
+			return err
+		}
+		return fmt.Errorf("falha")
+	})
+	assert.Error(t, err)
+
+	_, err = productDB.GetByID(created.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = productDB.GetByID(existing.ID.String())
+	assert.NoError(t, err)
+	count, err := auditDB.Count(AuditQuery{EntityID: created.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// Sem erro tudo é gravado
+	err = transactor.Transaction(func(tx *Repositories) error {
+		if err := tx.Products.Create(created); err != nil {
+			return err
+		}
+		found, err := tx.Products.GetByID(created.ID.String())
+		if err != nil {
+			return err
+		}
+		before := found.AuditFields()
+		found.Price = money.New(2500, "BRL")
+		if err := tx.Products.Update(found.ID.String(), found); err != nil {
+			return err
+		}
+		entry, _ := entity.NewAuditEntry(nil, entity.AuditUpdate, entity.AuditProduct, found.ID, before, found.AuditFields())
+		return tx.Audit.Record(entry)
+	})
+	assert.NoError(t, err)
+
+	found, err := productDB.GetByID(created.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(2500, "BRL"), found.Price)
+	assert.Equal(t, 2, found.Version)
+	count, err = auditDB.Count(AuditQuery{EntityID: created.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -2,10 +2,13 @@ package database
 
 import (
 	"apis/internal/entity"
+	"errors"
 
 	"gorm.io/gorm"
 )
 
+var ErrEmailExists = errors.New("Email already registered")
+
 type User struct {
 	DB *gorm.DB
 }
@@ -15,14 +18,74 @@ func NewUser(db *gorm.DB) *User {
 }
 
 func (u *User) Create(user *entity.User) error {
-	return u.DB.Create(user).Error
+	err := u.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkEmailUnique(tx, user); err != nil {
+			return err
+		}
+		return tx.Create(user).Error
+	})
+	return emailConflict(err)
 }
 
+// GetByEmail ignora maiúsculas e espaços: os emails são gravados normalizados
 func (u *User) GetByEmail(email string) (*entity.User, error) {
 	var user entity.User
-	err := u.DB.Where("email = ?", email).First(&user).Error
+	err := u.DB.Where("email = ?", entity.NormalizeEmail(email)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+func (u *User) GetByID(id string) (*entity.User, error) {
+	var user entity.User
+	err := u.DB.Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *User) Update(user *entity.User) error {
+	err := u.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewUser(tx).GetByID(user.ID.String()); err != nil {
+			return err
+		}
+		if err := checkEmailUnique(tx, user); err != nil {
+			return err
+		}
+		return tx.Save(user).Error
+	})
+	return emailConflict(err)
+}
+
+func (u *User) Delete(id string) error {
+	user, err := u.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return u.DB.Delete(user).Error
+}
+
+// checkEmailUnique é o caminho rápido para o email repetido. Dois cadastros simultâneos
+// podem passar os dois pela contagem; aí quem barra o segundo é o índice único (ver emailConflict).
+func checkEmailUnique(tx *gorm.DB, user *entity.User) error {
+	var count int64
+	err := tx.Model(&entity.User{}).Where("email = ? AND id <> ?", user.Email, user.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailExists
+	}
+	return nil
+}
+
+// emailConflict traduz a violação do índice único de users.email, a única chave única da
+// tabela além do ID, para o mesmo erro da contagem
+func emailConflict(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailExists
+	}
+	return err
+}
@@ -5,19 +5,15 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func TestCreateUser(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	db, err := openTestDB("file::memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.User{})
-	db.AutoMigrate(&entity.User{})
-
 	user, _ := entity.NewUser("John", "j@j.com", "123456")
 	userDB := NewUser(db)
 
@@ -34,14 +30,11 @@ func TestCreateUser(t *testing.T) {
 }
 
 func TestGetByEmail(t *testing.T) {
-	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	db, err := openTestDB("file::memory:")
 	if err != nil {
 		t.Error(err)
 	}
 
-	db.Migrator().DropTable(&entity.User{})
-	db.AutoMigrate(&entity.User{})
-
 	user, _ := entity.NewUser("John", "j@j.com", "123456")
 	userDB := NewUser(db)
 
@@ -55,3 +48,110 @@ func TestGetByEmail(t *testing.T) {
 	assert.Equal(t, user.Email, userFound.Email)
 	assert.NotNil(t, userFound.Password)
 }
+
+func TestUserEmailIsUniqueAndCaseInsensitive(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	user, _ := entity.NewUser("John", "John@J.com", "123456")
+	userDB := NewUser(db)
+	assert.Nil(t, userDB.Create(user))
+
+	userFound, err := userDB.GetByEmail(" JOHN@j.com")
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+
+	duplicate, _ := entity.NewUser("Other John", "john@j.com ", "123456")
+	assert.ErrorIs(t, userDB.Create(duplicate), ErrEmailExists)
+
+	// Trocar para o email de outro usuário também é recusado; manter o próprio, não
+	other, _ := entity.NewUser("Mary", "mary@j.com", "123456")
+	assert.Nil(t, userDB.Create(other))
+	other.Email = "john@j.com"
+	assert.ErrorIs(t, userDB.Update(other), ErrEmailExists)
+	user.Name = "John Updated"
+	assert.Nil(t, userDB.Update(user))
+
+	// O índice único barra até escritas que não passam pelo repositório
+	assert.ErrorIs(t, db.Create(duplicate).Error, gorm.ErrDuplicatedKey)
+}
+
+func TestCreateUserConcurrentSignup(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Simula o cadastro concorrente: a outra conta entra depois da contagem e antes do INSERT,
+	// e só o índice único percebe o email repetido
+	user, _ := entity.NewUser("John", "john@j.com", "123456")
+	other, _ := entity.NewUser("Other John", "john@j.com", "123456")
+	err = db.Callback().Create().Before("gorm:create").Register("test:concurrent_signup", func(tx *gorm.DB) {
+		if created, ok := tx.Statement.Dest.(*entity.User); ok && created.ID == user.ID {
+			tx.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO users (id, name, email, password, role) VALUES (?, ?, ?, ?, ?)",
+				other.ID, other.Name, other.Email, other.Password, other.Role)
+		}
+	})
+	assert.Nil(t, err)
+	defer db.Callback().Create().Remove("test:concurrent_signup")
+
+	userDB := NewUser(db)
+	assert.ErrorIs(t, userDB.Create(user), ErrEmailExists)
+}
+
+func TestGetUserByID(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	user, _ := entity.NewUser("John", "j@j.com", "123456")
+	userDB := NewUser(db)
+
+	err = userDB.Create(user)
+	assert.Nil(t, err)
+
+	userFound, err := userDB.GetByID(user.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, userFound.ID)
+	assert.Equal(t, user.Email, userFound.Email)
+	assert.Equal(t, entity.RoleViewer, userFound.Role)
+}
+
+func TestUpdateUser(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	user, _ := entity.NewUser("John", "j@j.com", "123456")
+	userDB := NewUser(db)
+	assert.Nil(t, userDB.Create(user))
+
+	user.Name = "John Updated"
+	user.Email = "john@j.com"
+	assert.Nil(t, userDB.Update(user))
+
+	userFound, err := userDB.GetByID(user.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, "John Updated", userFound.Name)
+	assert.Equal(t, "john@j.com", userFound.Email)
+}
+
+func TestDeleteUser(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	user, _ := entity.NewUser("John", "j@j.com", "123456")
+	userDB := NewUser(db)
+	assert.Nil(t, userDB.Create(user))
+
+	assert.Nil(t, userDB.Delete(user.ID.String()))
+	userFound, err := userDB.GetByID(user.ID.String())
+	assert.Error(t, err)
+	assert.Nil(t, userFound)
+}
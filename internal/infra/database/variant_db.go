@@ -0,0 +1,111 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSKUExists            = errors.New("SKU already exists")
+	ErrVariantOptionsExists = errors.New("The product already has a variant with these options")
+)
+
+type Variant struct {
+	DB *gorm.DB
+}
+
+func NewVariant(db *gorm.DB) *Variant {
+	return &Variant{DB: db}
+}
+
+func (v *Variant) Create(variant *entity.Variant) error {
+	return v.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantUnique(tx, variant); err != nil {
+			return err
+		}
+		return tx.Create(variant).Error
+	})
+}
+
+func (v *Variant) GetByID(id string) (*entity.Variant, error) {
+	var variant entity.Variant
+	if err := v.DB.Where("id = ?", id).First(&variant).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// GetByProduct retorna as variantes do produto, ordenadas por SKU
+func (v *Variant) GetByProduct(productID string) ([]entity.Variant, error) {
+	variants := []entity.Variant{}
+	if err := v.DB.Where("product_id = ?", productID).Order("sku").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// Update grava SKU, opções e preço; a variante continua no mesmo produto
+func (v *Variant) Update(variant *entity.Variant) error {
+	return v.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := NewVariant(tx).GetByID(variant.ID.String()); err != nil {
+			return err
+		}
+		if err := checkVariantUnique(tx, variant); err != nil {
+			return err
+		}
+		variant.UpdatedAt = time.Now()
+		fields := map[string]interface{}{
+			"sku":            variant.SKU,
+			"options":        variant.Options,
+			"options_key":    variant.OptionsKey,
+			"price_amount":   nil,
+			"price_currency": nil,
+			"updated_at":     variant.UpdatedAt,
+		}
+		// Sem preço as colunas voltam a NULL e a variante usa o preço do produto
+		if variant.Price != nil {
+			fields["price_amount"] = variant.Price.Amount
+			fields["price_currency"] = variant.Price.Currency
+		}
+		return tx.Model(variant).Updates(fields).Error
+	})
+}
+
+// Delete remove a variante junto com seu estoque
+func (v *Variant) Delete(id string) error {
+	return v.DB.Transaction(func(tx *gorm.DB) error {
+		variant, err := NewVariant(tx).GetByID(id)
+		if err != nil {
+			return err
+		}
+		if err := deleteStockData(tx, "product_id = ?", id); err != nil {
+			return err
+		}
+		return tx.Delete(variant).Error
+	})
+}
+
+// checkVariantUnique impede SKUs repetidos e duas variantes do produto com as mesmas opções
+func checkVariantUnique(tx *gorm.DB, variant *entity.Variant) error {
+	var count int64
+	err := tx.Model(&entity.Variant{}).Where("sku = ? AND id <> ?", variant.SKU, variant.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSKUExists
+	}
+	err = tx.Model(&entity.Variant{}).
+		Where("product_id = ? AND options_key = ? AND id <> ?", variant.ProductID, variant.OptionsKey, variant.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVariantOptionsExists
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariants(t *testing.T) {
+	db, err := openTestDB("file::memory:")
+	if err != nil {
+		t.Error(err)
+	}
+
+	product, _ := entity.NewProduct("T-shirt", money.New(4990, "BRL"))
+	productDB := NewProduct(db)
+	assert.NoError(t, productDB.Create(product))
+
+	variantDB := NewVariant(db)
+	override := money.New(5490, "BRL")
+	large, _ := entity.NewVariant(product.ID, "TSHIRT-L-BLUE", map[string]string{"size": "L", "color": "blue"}, &override)
+	medium, _ := entity.NewVariant(product.ID, "TSHIRT-M-BLUE", map[string]string{"size": "M", "color": "blue"}, nil)
+	assert.NoError(t, variantDB.Create(large))
+	assert.NoError(t, variantDB.Create(medium))
+
+	sameSKU, _ := entity.NewVariant(product.ID, "tshirt-m-blue", map[string]string{"size": "S"}, nil)
+	assert.ErrorIs(t, variantDB.Create(sameSKU), ErrSKUExists)
+	sameOptions, _ := entity.NewVariant(product.ID, "TSHIRT-M-BLUE-2", map[string]string{"color": "blue", "size": "M"}, nil)
+	assert.ErrorIs(t, variantDB.Create(sameOptions), ErrVariantOptionsExists)
+
+	variants, err := variantDB.GetByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, "TSHIRT-L-BLUE", variants[0].SKU)
+	assert.Equal(t, override, *variants[0].Price)
+	assert.Nil(t, variants[1].Price)
+
+	// Remover o preço na atualização volta a usar o do produto
+	large.Set("TSHIRT-XL-BLUE", map[string]string{"size": "XL", "color": "blue"}, nil)
+	assert.NoError(t, variantDB.Update(large))
+	found, err := variantDB.GetByID(large.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "TSHIRT-XL-BLUE", found.SKU)
+	assert.Equal(t, "XL", found.Options["size"])
+	assert.Nil(t, found.Price)
+
+	medium.Set("TSHIRT-XL-BLUE", medium.Options, nil)
+	assert.ErrorIs(t, variantDB.Update(medium), ErrSKUExists)
+
+	// Cada variante tem estoque próprio, que sai junto com ela
+	stockDB := NewStock(db)
+	receipt, _ := entity.NewStockMovement(medium.ID, entity.StockReceipt, 5, "", entitypkg.NewID())
+	_, err = stockDB.Adjust(receipt)
+	assert.NoError(t, err)
+	assert.NoError(t, variantDB.Delete(medium.ID.String()))
+	movements, err := stockDB.Movements(medium.ID.String(), 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, movements)
+
+	// Remover o produto definitivamente remove as variantes
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+	variants, err = variantDB.GetByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, variants)
+}
@@ -0,0 +1,131 @@
+// Package exchange converte valores entre moedas usando cotações de um ExchangeRateProvider.
+package exchange
+
+import (
+	"apis/pkg/money"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrRateNotFound = errors.New("Exchange rate not found")
+	ErrInvalidRate  = errors.New("Invalid exchange rate")
+)
+
+// ExchangeRateProvider informa quantas unidades da moeda to valem uma unidade da moeda from
+type ExchangeRateProvider interface {
+	Rate(from, to string) (*big.Rat, error)
+}
+
+// Convert converte o valor para a moeda to, arredondando para a unidade menor mais próxima
+func Convert(provider ExchangeRateProvider, m money.Money, to string) (money.Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+	fromDecimals, err := money.Decimals(m.Currency)
+	if err != nil {
+		return money.Money{}, err
+	}
+	toDecimals, err := money.Decimals(to)
+	if err != nil {
+		return money.Money{}, err
+	}
+	rate, err := provider.Rate(m.Currency, to)
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	// amount / 10^fromDecimals * rate * 10^toDecimals
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+	value.Mul(value, pow10(toDecimals))
+	value.Quo(value, pow10(fromDecimals))
+
+	amount := roundHalfAwayFromZero(value)
+	if !amount.IsInt64() {
+		return money.Money{}, money.ErrInvalidAmount
+	}
+	return money.New(amount.Int64(), to), nil
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+func roundHalfAwayFromZero(value *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	// |resto| * 2 >= denominador: arredonda para longe do zero
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		if value.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+// StaticProvider usa uma tabela fixa de cotações em relação a uma moeda base:
+// rates["USD"] = 0.18 significa que 1 unidade da base vale 0.18 USD.
+type StaticProvider struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+func NewStaticProvider(base string, rates map[string]string) (*StaticProvider, error) {
+	if !money.IsValidCurrency(base) {
+		return nil, fmt.Errorf("%w: %s", money.ErrInvalidCurrency, base)
+	}
+	p := &StaticProvider{base: base, rates: make(map[string]*big.Rat, len(rates))}
+	for currency, raw := range rates {
+		if !money.IsValidCurrency(currency) {
+			return nil, fmt.Errorf("%w: %s", money.ErrInvalidCurrency, currency)
+		}
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(raw))
+		if !ok || rate.Sign() <= 0 || len(raw) > 32 {
+			return nil, fmt.Errorf("%w: %s=%s", ErrInvalidRate, currency, raw)
+		}
+		p.rates[currency] = rate
+	}
+	return p, nil
+}
+
+// Rate calcula a cotação cruzada passando pela moeda base
+func (p *StaticProvider) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := p.rate(from)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRateNotFound, from)
+	}
+	toRate, ok := p.rate(to)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRateNotFound, to)
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+func (p *StaticProvider) rate(currency string) (*big.Rat, bool) {
+	if currency == p.base {
+		return big.NewRat(1, 1), true
+	}
+	rate, ok := p.rates[currency]
+	return rate, ok
+}
+
+// ParseRates lê a tabela no formato "USD=0.18,EUR=0.16", usado na configuração
+func ParseRates(value string) (map[string]string, error) {
+	rates := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, rate, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRate, pair)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(currency))] = strings.TrimSpace(rate)
+	}
+	return rates, nil
+}
@@ -0,0 +1,108 @@
+package exchange
+
+import (
+	"apis/pkg/money"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticProviderConvert(t *testing.T) {
+	provider, err := NewStaticProvider("BRL", map[string]string{"USD": "0.2", "EUR": "0.16", "JPY": "30"})
+	assert.NoError(t, err)
+
+	converted, err := Convert(provider, money.New(149999, "BRL"), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(30000, "USD"), converted) // 299.9998 arredonda para 300.00
+
+	// Cotação cruzada passando pela base: 1 USD = 0.8 EUR
+	converted, err = Convert(provider, money.New(1000, "USD"), "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(800, "EUR"), converted)
+
+	// Moedas com casas decimais diferentes
+	converted, err = Convert(provider, money.New(1000, "BRL"), "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(300, "JPY"), converted)
+
+	converted, err = Convert(provider, money.New(1000, "BRL"), "BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(1000, "BRL"), converted)
+
+	_, err = Convert(provider, money.New(1000, "BRL"), "GBP")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = Convert(provider, money.New(1000, "BRL"), "XYZ")
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+}
+
+func TestRoundHalfAwayFromZero(t *testing.T) {
+	provider, err := NewStaticProvider("BRL", map[string]string{"USD": "0.5"})
+	assert.NoError(t, err)
+
+	converted, err := Convert(provider, money.New(1, "BRL"), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), converted.Amount) // 0.5 centavo sobe
+
+	converted, err = Convert(provider, money.New(-1, "BRL"), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), converted.Amount)
+}
+
+func TestNewStaticProviderInvalid(t *testing.T) {
+	_, err := NewStaticProvider("XYZ", nil)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	_, err = NewStaticProvider("BRL", map[string]string{"USD": "0"})
+	assert.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = NewStaticProvider("BRL", map[string]string{"USD": "abc"})
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates("usd=0.18, EUR=0.16,")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"USD": "0.18", "EUR": "0.16"}, rates)
+
+	_, err = ParseRates("USD")
+	assert.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"base": "BRL", "rates": {"USD": "0.2", "EUR": 0.16}}`), 0o644))
+
+	provider, err := NewFileProvider(path)
+	assert.NoError(t, err)
+
+	converted, err := Convert(provider, money.New(1000, "BRL"), "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(160, "EUR"), converted)
+
+	// Alterações no arquivo são recarregadas
+	assert.NoError(t, os.WriteFile(path, []byte(`{"base": "BRL", "rates": {"USD": "0.25"}}`), 0o644))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, later, later))
+
+	converted, err = Convert(provider, money.New(1000, "BRL"), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(250, "USD"), converted)
+	_, err = Convert(provider, money.New(1000, "BRL"), "EUR")
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	// Um arquivo inválido mantém as cotações anteriores
+	assert.NoError(t, os.WriteFile(path, []byte(`{`), 0o644))
+	later = later.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, later, later))
+
+	converted, err = Convert(provider, money.New(1000, "BRL"), "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(250, "USD"), converted)
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// rateFile é o formato do arquivo lido pelo FileProvider:
+// {"base": "BRL", "rates": {"USD": "0.18", "EUR": 0.16}}
+type rateFile struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// FileProvider lê as cotações de um arquivo JSON local e o recarrega quando ele é alterado,
+// então as cotações podem ser atualizadas sem reiniciar o servidor e sem acesso à rede.
+type FileProvider struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	current *StaticProvider
+}
+
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *FileProvider) Rate(from, to string) (*big.Rat, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Se o arquivo novo estiver inválido, as cotações anteriores continuam valendo
+	if info, err := os.Stat(p.path); err == nil && !info.ModTime().Equal(p.modTime) {
+		if err := p.load(info.ModTime()); err != nil {
+			log.Printf("erro ao recarregar cotações de %s: %v", p.path, err)
+		}
+	}
+	return p.current.Rate(from, to)
+}
+
+func (p *FileProvider) load(modTime time.Time) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRate, err)
+	}
+	rates := make(map[string]string, len(file.Rates))
+	for currency, rate := range file.Rates {
+		rates[currency] = rate.String()
+	}
+	provider, err := NewStaticProvider(file.Base, rates)
+	if err != nil {
+		return err
+	}
+	p.current = provider
+	p.modTime = modTime
+	return nil
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AuditHandler struct {
+	AuditDB   database.AuditInterface
+	ProductDB database.ProductInterface
+}
+
+func NewAuditHandler(auditDB database.AuditInterface, productDB database.ProductInterface) *AuditHandler {
+	return &AuditHandler{
+		AuditDB:   auditDB,
+		ProductDB: productDB,
+	}
+}
+
+// auditQueryParams são os parâmetros aceitos em GET /audit; o histórico de um produto
+// aceita os mesmos, exceto entity_type e entity_id
+var auditQueryParams = map[string]bool{
+	"entity_type": true, "entity_id": true, "actor": true, "action": true,
+	"since": true, "until": true, "page": true, "limit": true,
+}
+
+// History godoc
+// @Summary Product change history
+// @Description List who changed a product, when, and the before/after value of each changed field, newest first. The history is kept after the product is deleted or purged. Changes to variants, images and stock are update entries whose fields are prefixed with variants.<id>, images.<id> and stock.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param actor query string false "Only changes made by this user ID"
+// @Param action query string false "create, update, delete, restore or purge"
+// @Param since query string false "Only changes at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param until query string false "Only changes at or before this time (RFC 3339, or YYYY-MM-DD to include that whole day)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} dto.AuditPageOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/history [get]
+// @Security ApiKeyAuth
+func (h *AuditHandler) History(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	values := r.URL.Query()
+	for _, key := range []string{"entity_type", "entity_id"} {
+		if values.Has(key) {
+			writeError(w, http.StatusBadRequest, "Unknown query parameter: "+key)
+			return
+		}
+	}
+	query, err := parseAuditQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.EntityType = entity.AuditProduct
+	query.EntityID = id
+
+	total, err := h.AuditDB.Count(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error counting history")
+		return
+	}
+	// Produtos anteriores ao log de auditoria existem mas não têm histórico
+	if total == 0 {
+		if _, err := h.ProductDB.GetByID(id); err != nil {
+			writeError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+	}
+	h.writeAuditPage(w, r, query, total)
+}
+
+// GetAll Audit godoc
+// @Summary Search the audit log
+// @Description List the changes made to products and users, newest first. Admin only.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param entity_type query string false "product or user"
+// @Param entity_id query string false "ID of the changed product or user"
+// @Param actor query string false "Only changes made by this user ID"
+// @Param action query string false "create, update, delete, restore or purge"
+// @Param since query string false "Only changes at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param until query string false "Only changes at or before this time (RFC 3339, or YYYY-MM-DD to include that whole day)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} dto.AuditPageOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /audit [get]
+// @Security ApiKeyAuth
+func (h *AuditHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query, err := parseAuditQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	total, err := h.AuditDB.Count(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error counting audit entries")
+		return
+	}
+	h.writeAuditPage(w, r, query, total)
+}
+
+func (h *AuditHandler) writeAuditPage(w http.ResponseWriter, r *http.Request, query database.AuditQuery, total int64) {
+	if query.Limit > 0 && query.Page <= 0 {
+		query.Page = 1
+	}
+	entries, err := h.AuditDB.Search(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching audit entries")
+		return
+	}
+
+	// Sem limit a listagem inteira cabe numa única página
+	page, limit := query.Page, query.Limit
+	if limit <= 0 {
+		page, limit = 1, int(total)
+	}
+	pages := totalPages(total, limit)
+	if query.Limit > 0 {
+		setPaginationLinks(w, r, page, pages)
+	}
+
+	writeJSON(w, http.StatusOK, dto.AuditPageOutput{
+		Items:      entries,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: pages,
+	})
+}
+
+// parseAuditQuery converte a query string em database.AuditQuery
+func parseAuditQuery(r *http.Request) (database.AuditQuery, error) {
+	values := r.URL.Query()
+	for key := range values {
+		if !auditQueryParams[key] {
+			return database.AuditQuery{}, fmt.Errorf("Unknown query parameter: %s", key)
+		}
+	}
+
+	page, err := strconv.Atoi(values.Get("page"))
+	if err != nil {
+		page = 0
+	}
+	limit, err := strconv.Atoi(values.Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+	query := database.AuditQuery{
+		EntityType: values.Get("entity_type"),
+		EntityID:   values.Get("entity_id"),
+		ActorID:    values.Get("actor"),
+		Action:     entity.AuditAction(values.Get("action")),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	if query.EntityType != "" && query.EntityType != entity.AuditProduct && query.EntityType != entity.AuditUser {
+		return database.AuditQuery{}, errors.New("Invalid entity_type: use product or user")
+	}
+	if query.EntityID != "" {
+		if _, err := entitypkg.ParseID(query.EntityID); err != nil {
+			return database.AuditQuery{}, errors.New("Invalid entity_id")
+		}
+	}
+	if query.ActorID != "" {
+		if _, err := entitypkg.ParseID(query.ActorID); err != nil {
+			return database.AuditQuery{}, errors.New("Invalid actor")
+		}
+	}
+	if query.Since, err = parseTimeParam(values, "since"); err != nil {
+		return database.AuditQuery{}, err
+	}
+	if query.Until, err = parseTimeUpperBound(values, "until"); err != nil {
+		return database.AuditQuery{}, err
+	}
+
+	if err := query.Validate(); err != nil {
+		return database.AuditQuery{}, err
+	}
+	return query, nil
+}
+
+// newAuditEntry monta uma entrada de auditoria com o usuário do token como autor
+func newAuditEntry(r *http.Request, action entity.AuditAction, entityType string, entityID entitypkg.ID, before, after entity.AuditFields) (*entity.AuditEntry, error) {
+	var actor *entitypkg.ID
+	if userID, err := userIDFromRequest(r); err == nil {
+		actor = &userID
+	}
+	return entity.NewAuditEntry(actor, action, entityType, entityID, before, after)
+}
+
+// recordAudit grava a entrada de auditoria de uma alteração. auditDB deve ser o da transação
+// da própria alteração (Transactor.Transaction): se a entrada não puder ser gravada, o erro
+// desfaz a alteração, e o log nunca deixa de registrar uma mudança que foi gravada.
+func recordAudit(auditDB database.AuditInterface, r *http.Request, action entity.AuditAction, entityType string, entityID entitypkg.ID, before, after entity.AuditFields) error {
+	entry, err := newAuditEntry(r, action, entityType, entityID, before, after)
+	if err != nil {
+		return err
+	}
+	return auditDB.Record(entry)
+}
+
+// recordProductChanges registra uma entrada de atualização para cada produto alterado a partir
+// de outra entidade, como na remoção de uma categoria ou tag, na mesma transação dela
+func recordProductChanges(auditDB database.AuditInterface, r *http.Request, changes []database.ProductChange) error {
+	entries := make([]*entity.AuditEntry, len(changes))
+	for i, change := range changes {
+		entry, err := newAuditEntry(r, entity.AuditUpdate, entity.AuditProduct, change.After.ID, change.Before.AuditFields(), change.After.AuditFields())
+		if err != nil {
+			return err
+		}
+		entries[i] = entry
+	}
+	return auditDB.Record(entries...)
+}
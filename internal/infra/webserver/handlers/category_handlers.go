@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	CategoryDB database.CategoryInterface
+	Transactor database.TransactorInterface // grava a alteração e a auditoria dos produtos afetados juntas
+}
+
+func NewCategoryHandler(db database.CategoryInterface, transactor database.TransactorInterface) *CategoryHandler {
+	return &CategoryHandler{CategoryDB: db, Transactor: transactor}
+}
+
+// Create Category godoc
+// @Summary Create a category
+// @Description Create a category. Send parent_id to nest it under an existing category.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body dto.CategoryInput true "Category"
+// @Success 201 {object} entity.Category
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /categories [post]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input dto.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	parentID, err := parseParentID(input.ParentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	category, err := entity.NewCategory(input.Name, parentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.CategoryDB.Create(category); err != nil {
+		if errors.Is(err, database.ErrParentCategoryNotFound) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error creating category")
+		return
+	}
+	writeJSON(w, http.StatusCreated, category)
+}
+
+// GetAll Categories godoc
+// @Summary List categories
+// @Description List all categories in alphabetical order. With tree=true the root categories are returned with their subcategories nested in children.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param tree query bool false "Return the categories as a tree"
+// @Success 200 {array} entity.Category
+// @Failure 400 {object} Error
+// @Failure 500 {object} Error
+// @Router /categories [get]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	tree := false
+	if value := r.URL.Query().Get("tree"); value != "" {
+		var err error
+		if tree, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid tree")
+			return
+		}
+	}
+
+	categories, err := h.CategoryDB.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching categories")
+		return
+	}
+	if categories == nil {
+		categories = []entity.Category{}
+	}
+	if tree {
+		writeJSON(w, http.StatusOK, buildCategoryTree(categories))
+		return
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+// GetByID Category godoc
+// @Summary Get a category
+// @Description Get a category by ID
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} entity.Category
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /categories/{id} [get]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	category, err := h.CategoryDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, category)
+}
+
+// Update Category godoc
+// @Summary Update a category
+// @Description Rename or move a category. A null parent_id makes it a root category; it cannot be moved under one of its own subcategories.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body dto.CategoryInput true "Category"
+// @Success 200 {object} entity.Category
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /categories/{id} [put]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	var input dto.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	parentID, err := parseParentID(input.ParentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	category, err := h.CategoryDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	category.Name = strings.TrimSpace(input.Name)
+	category.ParentID = parentID
+	category.UpdatedAt = time.Now()
+	if err := category.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.CategoryDB.Update(category); err != nil {
+		switch {
+		case errors.Is(err, database.ErrParentCategoryNotFound):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, database.ErrCategoryCycle):
+			writeError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, http.StatusNotFound, "Category not found")
+		default:
+			writeError(w, http.StatusInternalServerError, "Error updating category")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, category)
+}
+
+// Delete Category godoc
+// @Summary Delete a category
+// @Description Delete a category without subcategories. Its products are left without a category.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /categories/{id} [delete]
+// @Security ApiKeyAuth
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	// Os produtos que perdem a categoria entram no log de auditoria
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		changes, err := tx.Categories.Delete(id)
+		if err != nil {
+			return err
+		}
+		return recordProductChanges(tx.Audit, r, changes)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, http.StatusNotFound, "Category not found")
+		case errors.Is(err, database.ErrCategoryHasChildren):
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Error deleting category")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseParentID aceita parent_id ausente, nulo ou vazio como categoria raiz
+func parseParentID(value *string) (*entitypkg.ID, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	id, err := entitypkg.ParseID(*value)
+	if err != nil {
+		return nil, errors.New("Invalid parent_id")
+	}
+	return &id, nil
+}
+
+// buildCategoryTree monta a árvore a partir da lista plana, mantendo a ordem alfabética em cada nível
+func buildCategoryTree(categories []entity.Category) []*entity.Category {
+	nodes := make(map[entitypkg.ID]*entity.Category, len(categories))
+	for i := range categories {
+		nodes[categories[i].ID] = &categories[i]
+	}
+	roots := []*entity.Category{}
+	for i := range categories {
+		category := &categories[i]
+		var parent *entity.Category
+		if category.ParentID != nil {
+			parent = nodes[*category.ParentID]
+		}
+		if parent == nil {
+			roots = append(roots, category)
+			continue
+		}
+		parent.Children = append(parent.Children, category)
+	}
+	return roots
+}
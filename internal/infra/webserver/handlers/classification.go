@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidCategoryID = errors.New("Invalid category_id")
+	errCategoryNotFound  = errors.New("Category not found")
+	errTagNotFound       = errors.New("Tag not found")
+)
+
+// classifyProduct define a categoria e as tags do produto a partir da entrada.
+// Tags são referenciadas pelo nome e precisam existir; categoria nil deixa o produto sem categoria.
+// Dentro de uma transação, use os repositórios dela: assim a leitura vê o mesmo estado que a
+// gravação e não precisa de outra conexão do pool.
+func classifyProduct(categoryDB database.CategoryInterface, tagDB database.TagInterface, product *entity.Product, categoryID *string, tagNames []string) error {
+	product.CategoryID = nil
+	if categoryID != nil && *categoryID != "" {
+		id, err := entitypkg.ParseID(*categoryID)
+		if err != nil {
+			return errInvalidCategoryID
+		}
+		if _, err := categoryDB.GetByID(id.String()); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", errCategoryNotFound, id)
+			}
+			return err
+		}
+		product.CategoryID = &id
+	}
+
+	names := normalizeTagNames(tagNames)
+	tags, err := tagDB.GetByNames(names)
+	if err != nil {
+		return err
+	}
+	if len(tags) != len(names) {
+		found := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			found[tag.Name] = true
+		}
+		for _, name := range names {
+			if !found[name] {
+				return fmt.Errorf("%w: %s", errTagNotFound, name)
+			}
+		}
+	}
+	product.Tags = tags
+	return nil
+}
+
+// normalizeTagNames aplica a mesma normalização da criação de tags e remove repetições e vazios
+func normalizeTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = entity.NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+func isClassificationError(err error) bool {
+	return errors.Is(err, errInvalidCategoryID) || errors.Is(err, errCategoryNotFound) || errors.Is(err, errTagNotFound)
+}
+
+// writeClassificationError responde com status para categoria ou tag inválida e 500 para falhas do banco
+func writeClassificationError(w http.ResponseWriter, status int, err error) {
+	if isClassificationError(err) {
+		writeError(w, status, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "Error loading categories and tags")
+}
+
+// parseTagParam lê ?tag=, que pode se repetir ou trazer nomes separados por vírgula
+func parseTagParam(values []string) []string {
+	var names []string
+	for _, value := range values {
+		names = append(names, strings.Split(value, ",")...)
+	}
+	return normalizeTagNames(names)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// versionETag gera a ETag forte de um recurso versionado
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatchFails verifica o header If-Match (RFC 9110) contra a ETag atual.
+// Sem If-Match a pré-condição é considerada satisfeita. ETags fracas nunca casam.
+func ifMatchFails(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return false
+		}
+	}
+	return true
+}
+
+// ifNoneMatchHits diz se o cliente já tem a versão etag em cache (If-None-Match),
+// caso em que a resposta deve ser 304. Etags fracas (W/) também valem, como manda a RFC 9110.
+func ifNoneMatchHits(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
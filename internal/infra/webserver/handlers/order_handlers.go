@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OrderHandler struct {
+	OrderDB   database.OrderInterface
+	ProductDB database.ProductInterface
+}
+
+func NewOrderHandler(orderDB database.OrderInterface, productDB database.ProductInterface) *OrderHandler {
+	return &OrderHandler{
+		OrderDB:   orderDB,
+		ProductDB: productDB,
+	}
+}
+
+// Create Order godoc
+// @Summary Create a new order
+// @Description Create a new order for the authenticated user. Prices are taken from the stored products. Each item quantity must be between 1 and 10000.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body dto.CreateOrderInput true "Order"
+// @Success 201 {object} entity.Order
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 500 {object} Error
+// @Router /orders [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	var input dto.CreateOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// O preço de cada item vem do banco, nunca do cliente
+	items := make([]entity.OrderItem, 0, len(input.Items))
+	for _, itemInput := range input.Items {
+		if _, err := entitypkg.ParseID(itemInput.ProductID); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid product ID: "+itemInput.ProductID)
+			return
+		}
+		product, err := h.ProductDB.GetByID(itemInput.ProductID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Product not found: "+itemInput.ProductID)
+			return
+		}
+		item, err := entity.NewOrderItem(product, itemInput.Quantity)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		items = append(items, *item)
+	}
+
+	order, err := entity.NewOrder(userID, items)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.OrderDB.Create(order); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error creating order")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, order)
+}
+
+// GetAll godoc
+// @Summary List orders
+// @Description List the orders of the authenticated user
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Param sort query string false "Sort by created_at (asc or desc)"
+// @Success 200 {array} entity.Order
+// @Failure 401 {object} Error
+// @Failure 500 {object} Error
+// @Router /orders [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		pageInt = 0
+	}
+	limitInt, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limitInt = 0
+	}
+	sort := r.URL.Query().Get("sort")
+
+	orders, err := h.OrderDB.GetAllByUser(userID.String(), pageInt, limitInt, sort)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching orders")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// GetByID godoc
+// @Summary Get order by ID
+// @Description Get an order of the authenticated user by ID
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /orders/{id} [get]
+// @Security ApiKeyAuth
+func (h *OrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.findOwnOrder(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// Cancel godoc
+// @Summary Cancel an order
+// @Description Cancel an order of the authenticated user
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /orders/{id}/cancel [post]
+// @Security ApiKeyAuth
+func (h *OrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.findOwnOrder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := order.Cancel(); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err := h.OrderDB.Update(order); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error cancelling order")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// findOwnOrder busca o pedido da URL e garante que pertence ao usuário do token.
+// Pedidos de outros usuários respondem 404 para não revelar que existem.
+func (h *OrderHandler) findOwnOrder(w http.ResponseWriter, r *http.Request) (*entity.Order, bool) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return nil, false
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid order ID")
+		return nil, false
+	}
+
+	order, err := h.OrderDB.GetByID(id)
+	if err != nil || order.UserID != userID {
+		writeError(w, http.StatusNotFound, "Order not found")
+		return nil, false
+	}
+
+	return order, true
+}
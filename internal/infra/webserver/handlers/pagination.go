@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// totalPages calcula o número de páginas para o total de registros e o tamanho de página
+func totalPages(total int64, limit int) int {
+	if total <= 0 || limit <= 0 {
+		return 0
+	}
+	return int((total + int64(limit) - 1) / int64(limit))
+}
+
+// setPaginationLinks escreve o header Link (RFC 8288) com first, prev, next e last.
+// Os links preservam os demais parâmetros da query string e trocam apenas "page".
+func setPaginationLinks(w http.ResponseWriter, r *http.Request, page, pages int) {
+	last := pages
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(p int, rel string) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(p))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if page > 1 {
+		prev := page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, link(prev, "prev"))
+	}
+	if page < pages {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// maxBatchOperations limita o tamanho de uma requisição de POST /products/batch
+const maxBatchOperations = 1000
+
+// errBatchFailed desfaz a transação de um lote atômico quando uma operação falha
+var errBatchFailed = errors.New("batch operation failed")
+
+// Batch godoc
+// @Summary Batch create, update and delete products
+// @Description Run a list of create, update and delete operations. By default the batch is atomic: it runs in one transaction and the first failure rolls everything back (422). With atomic=false each operation is committed on its own and the response reports which ones failed.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param atomic query bool false "All-or-nothing (default true)"
+// @Param batch body dto.ProductBatchInput true "Operations"
+// @Success 200 {object} dto.ProductBatchOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 422 {object} dto.ProductBatchOutput
+// @Failure 500 {object} Error
+// @Router /products/batch [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	atomic := true
+	if raw := r.URL.Query().Get("atomic"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid atomic")
+			return
+		}
+		atomic = value
+	}
+
+	var input dto.ProductBatchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+	if len(input.Operations) == 0 {
+		writeError(w, http.StatusBadRequest, "At least one operation is required")
+		return
+	}
+	if len(input.Operations) > maxBatchOperations {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("A batch accepts at most %d operations", maxBatchOperations))
+		return
+	}
+
+	output := dto.ProductBatchOutput{Atomic: atomic, Results: make([]dto.ProductBatchResult, 0, len(input.Operations))}
+
+	if !atomic {
+		// Cada operação tem a própria transação: as que falham não afetam as demais
+		for i, op := range input.Operations {
+			var result dto.ProductBatchResult
+			err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+				result = h.runBatchOperation(r, tx, i, op)
+				if result.Status >= 400 {
+					return errBatchFailed
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errBatchFailed) {
+				result = batchFailure(i, op, http.StatusInternalServerError, "Error committing operation")
+			}
+			if result.Status >= 400 {
+				output.Failed++
+			} else {
+				output.Succeeded++
+			}
+			output.Results = append(output.Results, result)
+		}
+		writeJSON(w, http.StatusOK, output)
+		return
+	}
+
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		for i, op := range input.Operations {
+			result := h.runBatchOperation(r, tx, i, op)
+			output.Results = append(output.Results, result)
+			if result.Status >= 400 {
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		output.Succeeded = len(output.Results)
+		writeJSON(w, http.StatusOK, output)
+		return
+	}
+	if !errors.Is(err, errBatchFailed) {
+		writeError(w, http.StatusInternalServerError, "Error committing batch")
+		return
+	}
+
+	// Nada foi gravado: as operações anteriores à falha foram desfeitas e as seguintes nem rodaram
+	failed := len(output.Results) - 1
+	for i := 0; i < failed; i++ {
+		output.Results[i] = batchFailure(i, input.Operations[i], http.StatusFailedDependency, "Rolled back")
+	}
+	for i := failed + 1; i < len(input.Operations); i++ {
+		output.Results = append(output.Results, batchFailure(i, input.Operations[i], http.StatusFailedDependency, "Not executed"))
+	}
+	output.Failed = len(output.Results)
+	writeJSON(w, http.StatusUnprocessableEntity, output)
+}
+
+// runBatchOperation aplica uma operação com as mesmas regras das rotas individuais e grava
+// a entrada de auditoria dela na transação tx, que é desfeita se a operação falhar
+func (h *ProductHandler) runBatchOperation(r *http.Request, tx *database.Repositories, index int, op dto.ProductBatchOperation) dto.ProductBatchResult {
+	switch op.Op {
+	case "create":
+		return h.batchCreate(r, tx, index, op)
+	case "update", "delete":
+		return h.batchModify(r, tx, index, op)
+	}
+	return batchFailure(index, op, http.StatusBadRequest, "Invalid op: use create, update or delete")
+}
+
+func (h *ProductHandler) batchCreate(r *http.Request, tx *database.Repositories, index int, op dto.ProductBatchOperation) dto.ProductBatchResult {
+	if op.Product == nil {
+		return batchFailure(index, op, http.StatusBadRequest, "product is required")
+	}
+	ownerID, err := userIDFromRequest(r)
+	if err != nil {
+		return batchFailure(index, op, http.StatusUnauthorized, "Invalid token subject")
+	}
+
+	if op.Product.Price == nil {
+		return batchFailure(index, op, http.StatusBadRequest, entity.ErrPriceIsRequired.Error())
+	}
+	price, err := op.Product.Price.Money(h.DefaultCurrency)
+	if err != nil {
+		return batchFailure(index, op, http.StatusBadRequest, err.Error())
+	}
+	var name string
+	if op.Product.Name != nil {
+		name = *op.Product.Name
+	}
+	product, err := entity.NewProduct(name, price)
+	if err != nil {
+		return batchFailure(index, op, http.StatusBadRequest, err.Error())
+	}
+	product.OwnerID = ownerID
+	if err := classifyProduct(tx.Categories, tx.Tags, product, op.Product.CategoryID, op.Product.Tags); err != nil {
+		return batchClassificationFailure(index, op, err)
+	}
+
+	if err := tx.Products.Create(product); err != nil {
+		return batchFailure(index, op, http.StatusInternalServerError, "Error creating product")
+	}
+	if err := recordAudit(tx.Audit, r, entity.AuditCreate, entity.AuditProduct, product.ID, nil, product.AuditFields()); err != nil {
+		return batchFailure(index, op, http.StatusInternalServerError, "Error creating product")
+	}
+	return dto.ProductBatchResult{Index: index, Op: op.Op, Status: http.StatusCreated, ID: product.ID.String(), Version: product.Version}
+}
+
+func (h *ProductHandler) batchModify(r *http.Request, tx *database.Repositories, index int, op dto.ProductBatchOperation) dto.ProductBatchResult {
+	if _, err := entitypkg.ParseID(op.ID); err != nil {
+		return batchFailure(index, op, http.StatusBadRequest, "Invalid product ID")
+	}
+	if op.Op == "update" && op.Product == nil {
+		return batchFailure(index, op, http.StatusBadRequest, "product is required")
+	}
+
+	product, err := tx.Products.GetByID(op.ID)
+	if err != nil {
+		return batchFailure(index, op, http.StatusNotFound, "Product not found")
+	}
+	if !canModifyProduct(r, product) {
+		return batchFailure(index, op, http.StatusForbidden, "Only the owner can "+op.Op+" this product")
+	}
+	// version faz o papel do If-Match da rota individual
+	if op.Version != nil && *op.Version != product.Version {
+		return batchFailure(index, op, http.StatusPreconditionFailed, "Product was modified by another request")
+	}
+
+	if op.Op == "delete" {
+		if err := tx.Products.Delete(op.ID, product.Version); err != nil {
+			if errors.Is(err, database.ErrVersionConflict) {
+				status := http.StatusConflict
+				if op.Version != nil {
+					status = http.StatusPreconditionFailed
+				}
+				return batchFailure(index, op, status, "Product was modified by another request")
+			}
+			return batchFailure(index, op, http.StatusInternalServerError, "Error deleting product")
+		}
+		if err := recordAudit(tx.Audit, r, entity.AuditDelete, entity.AuditProduct, product.ID, nil, nil); err != nil {
+			return batchFailure(index, op, http.StatusInternalServerError, "Error deleting product")
+		}
+		return dto.ProductBatchResult{Index: index, Op: op.Op, Status: http.StatusNoContent, ID: op.ID}
+	}
+
+	// update é uma substituição completa, como o PUT
+	if op.Product.Name == nil {
+		return batchFailure(index, op, http.StatusBadRequest, entity.ErrNameIsRequired.Error())
+	}
+	if op.Product.Price == nil {
+		return batchFailure(index, op, http.StatusBadRequest, entity.ErrPriceIsRequired.Error())
+	}
+	price, err := op.Product.Price.Money(h.DefaultCurrency)
+	if err != nil {
+		return batchFailure(index, op, http.StatusBadRequest, err.Error())
+	}
+	before := product.AuditFields()
+	product.Name = *op.Product.Name
+	product.Price = price
+	if err := product.Validate(); err != nil {
+		return batchFailure(index, op, http.StatusBadRequest, err.Error())
+	}
+	if err := classifyProduct(tx.Categories, tx.Tags, product, op.Product.CategoryID, op.Product.Tags); err != nil {
+		return batchClassificationFailure(index, op, err)
+	}
+	if err := tx.Products.Update(op.ID, product); err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			return batchFailure(index, op, http.StatusConflict, "Product was modified by another request")
+		}
+		return batchFailure(index, op, http.StatusInternalServerError, "Error updating product")
+	}
+	if err := recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, product.AuditFields()); err != nil {
+		return batchFailure(index, op, http.StatusInternalServerError, "Error updating product")
+	}
+	return dto.ProductBatchResult{Index: index, Op: op.Op, Status: http.StatusOK, ID: op.ID, Version: product.Version}
+}
+
+func batchClassificationFailure(index int, op dto.ProductBatchOperation, err error) dto.ProductBatchResult {
+	if isClassificationError(err) {
+		return batchFailure(index, op, http.StatusBadRequest, err.Error())
+	}
+	return batchFailure(index, op, http.StatusInternalServerError, "Error loading categories and tags")
+}
+
+func batchFailure(index int, op dto.ProductBatchOperation, status int, message string) dto.ProductBatchResult {
+	return dto.ProductBatchResult{Index: index, Op: op.Op, Status: status, ID: op.ID, Error: message}
+}
@@ -4,22 +4,47 @@ import (
 	"apis/internal/dto"
 	"apis/internal/entity"
 	"apis/internal/infra/database"
+	"apis/internal/infra/exchange"
 	entitypkg "apis/pkg/entity"
+	"apis/pkg/jsonpatch"
+	"apis/pkg/money"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"encoding/json"
 
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type ProductHandler struct {
-	ProductDB database.ProductInterface
+	ProductDB       database.ProductInterface
+	CategoryDB      database.CategoryInterface
+	TagDB           database.TagInterface
+	VariantDB       database.VariantInterface
+	Transactor      database.TransactorInterface  // grava a alteração e a entrada de auditoria juntas
+	DefaultCurrency string                        // moeda dos preços enviados sem currency
+	Rates           exchange.ExchangeRateProvider // cotações usadas em ?currency= quando não há preço explícito
+	MaxImportSize   int64                         // tamanho máximo do corpo de uma importação, em bytes
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
+func NewProductHandler(db database.ProductInterface, categoryDB database.CategoryInterface, tagDB database.TagInterface, variantDB database.VariantInterface, transactor database.TransactorInterface, defaultCurrency string, rates exchange.ExchangeRateProvider, maxImportSize int64) *ProductHandler {
 	return &ProductHandler{
-		ProductDB: db,
+		ProductDB:       db,
+		CategoryDB:      categoryDB,
+		TagDB:           tagDB,
+		VariantDB:       variantDB,
+		Transactor:      transactor,
+		DefaultCurrency: defaultCurrency,
+		Rates:           rates,
+		MaxImportSize:   maxImportSize,
 	}
 }
 
@@ -32,6 +57,7 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 // @Param product body dto.CreateProductInput true "Product"
 // @Success 201
 // @Failure 500 {object} Error
+// @Failure 403 {object} Error
 // @Router /products [post]
 // @Security ApiKeyAuth
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -43,13 +69,35 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := entity.NewProduct(productInput.Name, productInput.Price)
+	ownerID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	price, err := productInput.Price.Money(h.DefaultCurrency)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	err = h.ProductDB.Create(p)
+	p, err := entity.NewProduct(productInput.Name, price)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	p.OwnerID = ownerID
+	if err := classifyProduct(h.CategoryDB, h.TagDB, p, productInput.CategoryID, productInput.Tags); err != nil {
+		writeClassificationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Create(p); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditCreate, entity.AuditProduct, p.ID, nil, p.AuditFields())
+	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -65,7 +113,12 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param currency query string false "Also return localized_price in this currency (ISO 4217)"
+// @Param include query string false "Embed related data; 'variants' embeds the product variants"
+// @Param as_of query string false "Return the product as it was at this time (RFC 3339 or YYYY-MM-DD), rebuilt from its revisions; cannot be combined with include"
 // @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "Current product version; omitted with as_of"
+// @Failure 400 {object} Error
 // @Failure 404 {object} Error
 // @Failure 500 {object} Error
 // @Router /products/{id} [get]
@@ -77,11 +130,48 @@ func (p *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(Error{Message: "ID do produto é obrigatório"})
 		return
 	}
+	currency, err := parseCurrencyParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	includeVariants := false
+	switch r.URL.Query().Get("include") {
+	case "":
+	case "variants":
+		includeVariants = true
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid include: use variants")
+		return
+	}
+	asOf, err := parseTimeParam(r.URL.Query(), "as_of")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if asOf != nil {
+		if includeVariants {
+			writeError(w, http.StatusBadRequest, "as_of cannot be combined with include")
+			return
+		}
+		p.getAsOf(w, id, *asOf, currency)
+		return
+	}
 	product, err := p.ProductDB.GetByID(id)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if currency != "" {
+		p.localizePrice(product, currency)
+	}
+	if includeVariants {
+		if product.Variants, err = p.VariantDB.GetByProduct(product.ID.String()); err != nil {
+			writeError(w, http.StatusInternalServerError, "Error fetching variants")
+			return
+		}
+	}
+	w.Header().Set("ETag", versionETag(product.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(product)
@@ -89,59 +179,160 @@ func (p *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 // GetAll godoc
 // @Summary Get all products
-// @Description Get all products
+// @Description Get all products, optionally filtered and sorted. Unknown query parameters are rejected.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number"
 // @Param limit query int false "Page size"
-// @Param sort query string false "Sort by field"
-// @Success 200 {array} entity.Product
-// @Failure 404 {object} Error
+// @Param sort query string false "Sort order (asc or desc)"
+// @Param sort_by query string false "Sort field (name, price or created_at)"
+// @Param owner query string false "Filter by owner: 'me' or a user ID"
+// @Param name query string false "Name contains (case-insensitive)"
+// @Param name_prefix query string false "Name starts with (case-insensitive)"
+// @Param min_price query number false "Minimum price, in the default currency"
+// @Param max_price query number false "Maximum price, in the default currency"
+// @Param created_after query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created at or before (RFC 3339, or YYYY-MM-DD to include that whole day)"
+// @Param cursor query string false "Cursor mode: empty to start, then the next_cursor of the previous response"
+// @Param currency query string false "Also return localized_price in this currency: the explicit price or the converted base price"
+// @Param category query string false "Filter by category ID"
+// @Param include_descendants query bool false "With category, also include products of its subcategories"
+// @Param tag query string false "Filter by tag name; repeat or separate with commas to require several tags"
+// @Success 200 {object} dto.ProductPageOutput "Offset mode; cursor mode returns dto.ProductCursorPageOutput"
+// @Header 200 {string} Link "RFC 8288 links to the first, prev, next and last pages"
+// @Failure 400 {object} Error
 // @Failure 500 {object} Error
 // @Router /products [get]
 // @Security ApiKeyAuth
 func (p *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	page := r.URL.Query().Get("page")
-	limit := r.URL.Query().Get("limit")
-	pageInt, err := strconv.Atoi(page)
+	query, err := p.parseProductQuery(r)
 	if err != nil {
-		pageInt = 0
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	limitInt, err := strconv.Atoi(limit)
+	p.writeProductList(w, r, query)
+}
+
+// writeProductList executa a listagem no modo offset ou cursor, conforme a query string
+func (p *ProductHandler) writeProductList(w http.ResponseWriter, r *http.Request, query database.ProductQuery) {
+	currency, err := parseCurrencyParam(r)
 	if err != nil {
-		limitInt = 0
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Has("cursor") {
+		p.getAllWithCursor(w, r, query, currency)
+		return
+	}
+	if query.Limit > 0 && query.Page <= 0 {
+		query.Page = 1
 	}
-	sort := r.URL.Query().Get("sort")
 
-	products, err := p.ProductDB.GetAll(pageInt, limitInt, sort)
+	products, err := p.ProductDB.Search(query)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(Error{Message: "Erro ao buscar produtos"})
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar produtos")
 		return
 	}
-	if len(products) == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(Error{Message: "No products found"})
+	total, err := p.ProductDB.Count(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Erro ao contar produtos")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(products)
+	if products == nil {
+		products = []entity.Product{}
+	}
+	p.localizePrices(products, currency)
+
+	// Sem limit a listagem inteira cabe numa única página
+	page, limit := query.Page, query.Limit
+	if limit <= 0 {
+		page, limit = 1, int(total)
+	}
+	pages := totalPages(total, limit)
+	if query.Limit > 0 {
+		setPaginationLinks(w, r, page, pages)
+	}
+
+	writeJSON(w, http.StatusOK, dto.ProductPageOutput{
+		Items:      products,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: pages,
+	})
+}
+
+// getAllWithCursor lista produtos em modo keyset, ordenados por (created_at, id).
+// Não há total nem páginas; o cliente segue next_cursor até ele vir vazio.
+func (p *ProductHandler) getAllWithCursor(w http.ResponseWriter, r *http.Request, query database.ProductQuery, currency string) {
+	if r.URL.Query().Has("page") {
+		writeError(w, http.StatusBadRequest, "page cannot be combined with cursor")
+		return
+	}
+	if query.SortBy != "" && query.SortBy != "created_at" {
+		writeError(w, http.StatusBadRequest, database.ErrCursorSortField.Error())
+		return
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultCursorLimit
+	}
+
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		cursor, err := database.DecodeProductCursor(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		query.After = cursor
+	} else {
+		query.Page = 1
+	}
+
+	// Busca um registro a mais para saber se existe próxima página
+	query.Limit = limit + 1
+	products, err := p.ProductDB.Search(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar produtos")
+		return
+	}
+
+	output := dto.ProductCursorPageOutput{Items: products, Limit: limit}
+	if len(products) > limit {
+		output.Items = products[:limit]
+		output.NextCursor = database.NewProductCursor(output.Items[limit-1]).Encode()
+
+		next := r.URL.Query()
+		next.Set("cursor", output.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, next.Encode()))
+	}
+	if output.Items == nil {
+		output.Items = []entity.Product{}
+	}
+	p.localizePrices(output.Items, currency)
+
+	writeJSON(w, http.StatusOK, output)
 }
 
 // UpdateProduct godoc
-// @Summary Update a product
-// @Description Update a product
+// @Summary Replace a product
+// @Description Replace a product. Name and price are required; a missing category_id or tags leaves the product without them. Use PATCH for partial updates.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param product body dto.UpdateProductInput true "Product"
+// @Param If-Match header string false "ETag from GET /products/{id}"
 // @Success 200
+// @Header 200 {string} ETag "New product version"
 // @Failure 400 {object} Error
 // @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
 // @Failure 500 {object} Error
+// @Failure 403 {object} Error
 // @Router /products/{id} [put]
 // @Security ApiKeyAuth
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -158,8 +349,23 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// PUT é uma substituição completa: campos ausentes não são mantidos
+	if input.Name == nil {
+		writeError(w, http.StatusBadRequest, entity.ErrNameIsRequired.Error())
+		return
+	}
+	if input.Price == nil {
+		writeError(w, http.StatusBadRequest, entity.ErrPriceIsRequired.Error())
+		return
+	}
+	price, err := input.Price.Money(h.DefaultCurrency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Valida ID
-	_, err := entitypkg.ParseID(id)
+	_, err = entitypkg.ParseID(id)
 	if err != nil {
 		http.Error(w, "ID inválido", http.StatusBadRequest)
 		return
@@ -172,34 +378,213 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Atualiza somente os campos enviados
-	if input.Name != "" {
-		existingProduct.Name = input.Name
+	if !canModifyProduct(r, existingProduct) {
+		writeError(w, http.StatusForbidden, "Only the owner can update this product")
+		return
 	}
-	if input.Price != 0 {
-		existingProduct.Price = input.Price
+
+	if ifMatchFails(r, versionETag(existingProduct.Version)) {
+		writeError(w, http.StatusPreconditionFailed, "Product was modified by another request")
+		return
 	}
 
-	// Atualiza no banco
-	err = h.ProductDB.Update(id, existingProduct)
-	if err != nil {
-		http.Error(w, "Erro ao atualizar produto: "+err.Error(), http.StatusInternalServerError)
+	before := existingProduct.AuditFields()
+	existingProduct.Name = *input.Name
+	existingProduct.Price = price
+	if err := existingProduct.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := classifyProduct(h.CategoryDB, h.TagDB, existingProduct, input.CategoryID, input.Tags); err != nil {
+		writeClassificationError(w, http.StatusBadRequest, err)
 		return
 	}
 
+	if !h.saveProduct(w, r, existingProduct, before) {
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// Patch godoc
+// @Summary Patch a product
+// @Description Partially update a product with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), chosen by Content-Type. The patch is applied to {"name", "price", "category_id", "tags"}; other fields cannot be changed.
+// @Tags products
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param patch body dto.ProductPatchDocument true "Merge patch object or JSON Patch operations"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
+// @Failure 415 {object} Error
+// @Failure 422 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id} [patch]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		w.Header().Set("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType+" or "+jsonPatchContentType)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Error reading request body")
+		return
+	}
+
+	product, err := h.ProductDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can update this product")
+		return
+	}
+	if ifMatchFails(r, versionETag(product.Version)) {
+		writeError(w, http.StatusPreconditionFailed, "Product was modified by another request")
+		return
+	}
+
+	doc, err := json.Marshal(newProductPatchDocument(product))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error encoding product")
+		return
+	}
+	var patched []byte
+	if contentType == mergePatchContentType {
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	} else {
+		patched, err = jsonpatch.Apply(doc, patch)
+	}
+	if err != nil {
+		// Patch malformado é 400; patch válido que não se aplica ao estado atual é 409 (RFC 5789)
+		if errors.Is(err, jsonpatch.ErrInvalidPatch) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	// Campos desconhecidos ou com tipo errado no resultado tornam o patch inaplicável
+	var result dto.ProductPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Invalid patched product: "+err.Error())
+		return
+	}
+
+	price, err := result.Price.Money(h.DefaultCurrency)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	before := product.AuditFields()
+	product.Name = result.Name
+	product.Price = price
+	if err := product.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err := classifyProduct(h.CategoryDB, h.TagDB, product, result.CategoryID, result.Tags); err != nil {
+		writeClassificationError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if !h.saveProduct(w, r, product, before) {
+		return
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+// newProductPatchDocument monta o documento de PATCH com o estado atual do produto
+func newProductPatchDocument(product *entity.Product) dto.ProductPatchDocument {
+	doc := dto.ProductPatchDocument{
+		Name:  product.Name,
+		Price: dto.NewPriceInput(product.Price),
+		Tags:  make([]string, 0, len(product.Tags)),
+	}
+	if product.CategoryID != nil {
+		categoryID := product.CategoryID.String()
+		doc.CategoryID = &categoryID
+	}
+	for _, tag := range product.Tags {
+		doc.Tags = append(doc.Tags, tag.Name)
+	}
+	return doc
+}
+
+// saveProduct grava o produto condicionado à versão lida e, na mesma transação, registra
+// a diferença para before no log de auditoria; depois define a nova ETag.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func (h *ProductHandler) saveProduct(w http.ResponseWriter, r *http.Request, product *entity.Product, before entity.AuditFields) bool {
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Update(product.ID.String(), product); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, product.AuditFields())
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return false
+		}
+		http.Error(w, "Erro ao atualizar produto: "+err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	w.Header().Set("ETag", versionETag(product.Version))
+	return true
+}
+
+// writeVersionConflict responde quando outra requisição gravou entre a leitura e a escrita:
+// 412 se o cliente mandou If-Match, 409 caso contrário
+func writeVersionConflict(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") != "" {
+		writeError(w, http.StatusPreconditionFailed, "Product was modified by another request")
+		return
+	}
+	writeError(w, http.StatusConflict, "Product was modified by another request")
+}
+
 // Delete godoc
 // @Summary Delete a product
-// @Description Delete a product
+// @Description Move a product to the trash. It can be restored until it is purged. The delete only applies to the version that was read: if the product changes in between the API answers 412 (with If-Match) or 409.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag from GET /products/{id}"
 // @Success 204
 // @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
 // @Failure 500 {object} Error
+// @Failure 403 {object} Error
 // @Router /products/{id} [delete]
 // @Security ApiKeyAuth
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -208,10 +593,362 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	err := h.ProductDB.Delete(id)
+	product, err := h.ProductDB.GetByID(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can delete this product")
+		return
+	}
+	if ifMatchFails(r, versionETag(product.Version)) {
+		writeError(w, http.StatusPreconditionFailed, "Product was modified by another request")
+		return
+	}
+	// A remoção é condicionada à versão lida: uma alteração feita depois do If-Match não se perde
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Delete(id, product.Version); err != nil {
+			return err
+		}
+		// Ir para a lixeira não altera os dados do produto, então a entrada não tem alterações
+		return recordAudit(tx.Audit, r, entity.AuditDelete, entity.AuditProduct, product.ID, nil, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrVersionConflict):
+			writeVersionConflict(w, r)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, http.StatusNotFound, "Product not found")
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// Trash godoc
+// @Summary List trashed products
+// @Description List soft-deleted products. Accepts the same query parameters as GET /products. Admin only.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} dto.ProductPageOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/trash [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	query, err := h.parseProductQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query.Deleted = true
+	h.writeProductList(w, r, query)
+}
+
+// ByCategory godoc
+// @Summary List products of a category
+// @Description List the products of a category. Accepts the same query parameters as GET /products except category; include_descendants=true also lists products of all subcategories.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param include_descendants query bool false "Also include products of subcategories"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} dto.ProductPageOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /categories/{id}/products [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) ByCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+	if r.URL.Query().Has("category") {
+		writeError(w, http.StatusBadRequest, "Unknown query parameter: category")
+		return
+	}
+	if _, err := h.CategoryDB.GetByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Category not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error fetching category")
+		return
+	}
+
+	// include_descendants sozinho é rejeitado em GET /products, então é lido aqui
+	values := r.URL.Query()
+	includeDescendants := values.Get("include_descendants")
+	values.Del("include_descendants")
+	listRequest := r.Clone(r.Context())
+	listRequest.URL.RawQuery = values.Encode()
+
+	query, err := h.parseProductQuery(listRequest)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.CategoryIDs, err = h.categoryFilter(id, includeDescendants); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeProductList(w, r, query)
+}
+
+// Restore godoc
+// @Summary Restore a product
+// @Description Move a soft-deleted product out of the trash. Admin only.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} entity.Product
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/restore [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	productID, err := entitypkg.ParseID(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Restore(id); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditRestore, entity.AuditProduct, productID, nil, nil)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Product not found in trash")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error restoring product")
+		return
+	}
+
+	product, err := h.ProductDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching product")
+		return
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+// Purge godoc
+// @Summary Purge a product
+// @Description Permanently delete a product that is in the trash. Admin only.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/purge [delete]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	productID, err := entitypkg.ParseID(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Purge(id); err != nil {
+			return err
+		}
+		// O histórico continua disponível depois da remoção definitiva
+		return recordAudit(tx.Audit, r, entity.AuditPurge, entity.AuditProduct, productID, nil, nil)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Product not found in trash")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error purging product")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// canModifyProduct permite alterações apenas ao dono do produto ou a um admin
+func canModifyProduct(r *http.Request, product *entity.Product) bool {
+	if roleFromRequest(r) == entity.RoleAdmin {
+		return true
+	}
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		return false
+	}
+	return product.IsOwnedBy(userID)
+}
+
+// productQueryParams são os parâmetros aceitos em GET /products
+var productQueryParams = map[string]bool{
+	"page": true, "limit": true, "sort": true, "sort_by": true, "owner": true,
+	"name": true, "name_prefix": true, "min_price": true, "max_price": true,
+	"created_after": true, "created_before": true, "cursor": true, "currency": true,
+	"category": true, "include_descendants": true, "tag": true,
+}
+
+const defaultCursorLimit = 100
+
+// parseProductQuery converte a query string em database.ProductQuery.
+// Parâmetros desconhecidos ou com valores inválidos geram erro.
+func (h *ProductHandler) parseProductQuery(r *http.Request) (database.ProductQuery, error) {
+	values := r.URL.Query()
+	for key := range values {
+		if !productQueryParams[key] {
+			return database.ProductQuery{}, fmt.Errorf("Unknown query parameter: %s", key)
+		}
+	}
+
+	// page e limit inválidos continuam sendo ignorados, como antes
+	page, err := strconv.Atoi(values.Get("page"))
+	if err != nil {
+		page = 0
+	}
+	limit, err := strconv.Atoi(values.Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	query := database.ProductQuery{
+		Name:       values.Get("name"),
+		NamePrefix: values.Get("name_prefix"),
+		SortBy:     values.Get("sort_by"),
+		Sort:       values.Get("sort"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	switch owner := values.Get("owner"); owner {
+	case "":
+	case "me":
+		ownerID, err := userIDFromRequest(r)
+		if err != nil {
+			return database.ProductQuery{}, errors.New("Invalid token subject")
+		}
+		query.OwnerID = ownerID.String()
+	default:
+		if _, err := entitypkg.ParseID(owner); err != nil {
+			return database.ProductQuery{}, errors.New("Invalid owner")
+		}
+		query.OwnerID = owner
+	}
+
+	if query.MinPrice, err = parseMoneyParam(values, "min_price", h.DefaultCurrency); err != nil {
+		return database.ProductQuery{}, err
+	}
+	if query.MaxPrice, err = parseMoneyParam(values, "max_price", h.DefaultCurrency); err != nil {
+		return database.ProductQuery{}, err
+	}
+	if query.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return database.ProductQuery{}, err
+	}
+	if query.CreatedBefore, err = parseTimeUpperBound(values, "created_before"); err != nil {
+		return database.ProductQuery{}, err
+	}
+	query.Tags = parseTagParam(values["tag"])
+	if category := values.Get("category"); category != "" {
+		if query.CategoryIDs, err = h.categoryFilter(category, values.Get("include_descendants")); err != nil {
+			return database.ProductQuery{}, err
+		}
+	} else if values.Has("include_descendants") {
+		return database.ProductQuery{}, errors.New("include_descendants requires category")
+	}
+
+	if err := query.Validate(); err != nil {
+		return database.ProductQuery{}, err
+	}
+	return query, nil
+}
+
+// categoryFilter resolve o filtro de categoria; com include_descendants=true entram também as subcategorias
+func (h *ProductHandler) categoryFilter(categoryID, includeDescendants string) ([]string, error) {
+	if _, err := entitypkg.ParseID(categoryID); err != nil {
+		return nil, errors.New("Invalid category")
+	}
+	descendants := false
+	if includeDescendants != "" {
+		var err error
+		if descendants, err = strconv.ParseBool(includeDescendants); err != nil {
+			return nil, errors.New("Invalid include_descendants")
+		}
+	}
+	if !descendants {
+		return []string{categoryID}, nil
+	}
+	ids, err := h.CategoryDB.SubtreeIDs(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		// Categoria inexistente: o filtro não encontra nada, como no modo sem descendentes
+		return []string{categoryID}, nil
+	}
+	return ids, nil
+}
+
+// parseMoneyParam lê um valor decimal exato na moeda informada
+func parseMoneyParam(values url.Values, key, currency string) (*money.Money, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := money.Parse(raw, currency)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s", key)
+	}
+	return &v, nil
+}
+
+// parseTimeParam aceita RFC 3339 ou apenas a data (YYYY-MM-DD)
+func parseTimeParam(values url.Values, key string) (*time.Time, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("Invalid %s", key)
+}
+
+// parseTimeUpperBound é o parseTimeParam dos limites superiores inclusivos: uma data sem hora
+// vale até o fim daquele dia, e não até a meia-noite em que ele começa. O último microssegundo
+// é a maior precisão que todos os bancos gravam.
+func parseTimeUpperBound(values url.Values, key string) (*time.Time, error) {
+	t, err := parseTimeParam(values, key)
+	if err != nil || t == nil {
+		return t, err
+	}
+	if _, err := time.Parse(time.DateOnly, values.Get(key)); err == nil {
+		end := t.AddDate(0, 0, 1).Add(-time.Microsecond)
+		return &end, nil
+	}
+	return t, nil
+}
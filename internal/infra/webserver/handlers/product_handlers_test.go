@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeUpperBound(t *testing.T) {
+	// Só a data: vale até o fim do dia
+	bound, err := parseTimeUpperBound(url.Values{"created_before": {"2024-05-01"}}, "created_before")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.May, 1, 23, 59, 59, 999999000, time.UTC), *bound)
+
+	// Com hora o valor é usado como veio
+	bound, err = parseTimeUpperBound(url.Values{"created_before": {"2024-05-01T10:00:00Z"}}, "created_before")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC), *bound)
+
+	bound, err = parseTimeUpperBound(url.Values{}, "created_before")
+	assert.NoError(t, err)
+	assert.Nil(t, bound)
+
+	_, err = parseTimeUpperBound(url.Values{"created_before": {"yesterday"}}, "created_before")
+	assert.Error(t, err)
+}
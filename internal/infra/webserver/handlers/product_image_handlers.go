@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/blob"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/thumbnail"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	thumbnailSize = 256 // lado máximo da miniatura, em pixels
+	// as imagens nunca mudam de conteúdo (um novo envio gera outro ID), então podem ficar em cache
+	imageCacheControl = "public, max-age=31536000, immutable"
+)
+
+type ProductImageHandler struct {
+	ImageDB    database.ProductImageInterface
+	ProductDB  database.ProductInterface
+	Transactor database.TransactorInterface // grava a alteração e a entrada de auditoria do produto juntas
+	Store      blob.BlobStore
+	MaxSize    int64 // tamanho máximo do arquivo enviado, em bytes
+}
+
+func NewProductImageHandler(imageDB database.ProductImageInterface, productDB database.ProductInterface, transactor database.TransactorInterface, store blob.BlobStore, maxSize int64) *ProductImageHandler {
+	return &ProductImageHandler{
+		ImageDB:    imageDB,
+		ProductDB:  productDB,
+		Transactor: transactor,
+		Store:      store,
+		MaxSize:    maxSize,
+	}
+}
+
+// Upload godoc
+// @Summary Upload a product image
+// @Description Upload a JPEG, PNG or GIF image as the "file" field of a multipart form. The type is detected from the content, not from the file name. A thumbnail of at most 256x256 pixels is generated. Only the product owner or an admin can upload images.
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param file formData file true "Image file"
+// @Success 201 {object} entity.ProductImage
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 413 {object} Error
+// @Failure 415 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/images [post]
+// @Security ApiKeyAuth
+func (h *ProductImageHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can manage the images of this product")
+		return
+	}
+
+	data, ok := h.readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	if !entity.ImageContentTypes[contentType] {
+		writeError(w, http.StatusUnsupportedMediaType, entity.ErrUnsupportedImageType.Error())
+		return
+	}
+	// As dimensões são conferidas antes de decodificar a imagem inteira
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid image")
+		return
+	}
+	checksum := sha256.Sum256(data)
+	img, err := entity.NewProductImage(product.ID, contentType, int64(len(data)), config.Width, config.Height, hex.EncodeToString(checksum[:]))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	thumb, err := makeThumbnail(data, img.ThumbnailContentType())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid image")
+		return
+	}
+
+	if _, err := h.Store.Put(img.BlobKey(), img.ContentType, bytes.NewReader(data)); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+	if _, err := h.Store.Put(img.ThumbnailKey(), img.ThumbnailContentType(), bytes.NewReader(thumb)); err != nil {
+		h.deleteBlobs(img)
+		writeError(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+	// As imagens fazem parte do produto e entram no histórico dele
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Images.Create(img); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, nil, img.AuditFields())
+	})
+	if err != nil {
+		h.deleteBlobs(img)
+		writeError(w, http.StatusInternalServerError, "Error saving image")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, img)
+}
+
+// GetAll Images godoc
+// @Summary List product images
+// @Description List the images of a product in upload order
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} entity.ProductImage
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/images [get]
+// @Security ApiKeyAuth
+func (h *ProductImageHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	images, err := h.ImageDB.GetByProduct(product.ID.String())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching images")
+		return
+	}
+	writeJSON(w, http.StatusOK, images)
+}
+
+// Serve godoc
+// @Summary Download a product image
+// @Description Serve the image file. The route is public so that it can be used in img tags; responses carry an ETag and may be cached indefinitely.
+// @Tags images
+// @Produce image/jpeg,image/png,image/gif
+// @Param id path string true "Product ID"
+// @Param imageID path string true "Image ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} binary
+// @Success 304
+// @Header 200 {string} ETag "SHA-256 of the file"
+// @Header 200 {string} Cache-Control "public, max-age=31536000, immutable"
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/images/{imageID} [get]
+func (h *ProductImageHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	img, ok := h.findImage(w, r)
+	if !ok {
+		return
+	}
+	h.serveBlob(w, r, img.BlobKey(), img.ContentType, `"`+img.Checksum+`"`, img)
+}
+
+// ServeThumbnail godoc
+// @Summary Download a product image thumbnail
+// @Description Serve the thumbnail of an image (at most 256x256 pixels; JPEG for JPEG images and PNG otherwise). Public and cacheable like the image itself.
+// @Tags images
+// @Produce image/jpeg,image/png
+// @Param id path string true "Product ID"
+// @Param imageID path string true "Image ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} binary
+// @Success 304
+// @Header 200 {string} ETag "Thumbnail version"
+// @Header 200 {string} Cache-Control "public, max-age=31536000, immutable"
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/images/{imageID}/thumbnail [get]
+func (h *ProductImageHandler) ServeThumbnail(w http.ResponseWriter, r *http.Request) {
+	img, ok := h.findImage(w, r)
+	if !ok {
+		return
+	}
+	h.serveBlob(w, r, img.ThumbnailKey(), img.ThumbnailContentType(), `"`+img.Checksum+`-thumbnail"`, img)
+}
+
+// Delete Image godoc
+// @Summary Delete a product image
+// @Description Delete an image and its thumbnail. Only the product owner or an admin can delete images.
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param imageID path string true "Image ID"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/images/{imageID} [delete]
+// @Security ApiKeyAuth
+func (h *ProductImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can manage the images of this product")
+		return
+	}
+	img, ok := h.findImage(w, r)
+	if !ok {
+		return
+	}
+
+	// Os arquivos saem antes do registro: se algo falhar no meio, repetir o DELETE termina o serviço
+	if err := h.deleteBlobs(img); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error deleting image")
+		return
+	}
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Images.Delete(img.ID.String()); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, img.AuditFields(), nil)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error deleting image")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteOrphans remove os arquivos e os registros das imagens de produtos que já foram
+// removidos definitivamente e retorna quantas imagens foram apagadas
+func (h *ProductImageHandler) DeleteOrphans() (int, error) {
+	deleted := 0
+	for {
+		orphans, err := h.ImageDB.Orphans(100)
+		if err != nil || len(orphans) == 0 {
+			return deleted, err
+		}
+		for i := range orphans {
+			if err := h.deleteBlobs(&orphans[i]); err != nil {
+				return deleted, err
+			}
+			if err := h.ImageDB.Delete(orphans[i].ID.String()); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+}
+
+// readUpload lê o campo "file" do formulário multipart sem passar de MaxSize bytes.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func (h *ProductImageHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	// folga para os cabeçalhos do multipart
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxSize+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Expected a multipart/form-data body")
+		return nil, false
+	}
+
+	tooLarge := "Image is larger than " + strconv.FormatInt(h.MaxSize, 10) + " bytes"
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeError(w, http.StatusBadRequest, "Missing file field")
+			return nil, false
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return nil, false
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid multipart body")
+			return nil, false
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, h.MaxSize+1))
+		if errors.As(err, &maxBytesErr) || int64(len(data)) > h.MaxSize {
+			writeError(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return nil, false
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid multipart body")
+			return nil, false
+		}
+		if len(data) == 0 {
+			writeError(w, http.StatusBadRequest, "Empty file")
+			return nil, false
+		}
+		return data, true
+	}
+}
+
+// findImage busca a imagem da URL, que precisa ser do produto da URL
+func (h *ProductImageHandler) findImage(w http.ResponseWriter, r *http.Request) (*entity.ProductImage, bool) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return nil, false
+	}
+	id := chi.URLParam(r, "imageID")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid image ID")
+		return nil, false
+	}
+	img, err := h.ImageDB.GetByID(id)
+	if err != nil || img.ProductID != product.ID {
+		writeError(w, http.StatusNotFound, "Image not found")
+		return nil, false
+	}
+	return img, true
+}
+
+func (h *ProductImageHandler) serveBlob(w http.ResponseWriter, r *http.Request, key, contentType, etag string, img *entity.ProductImage) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", imageCacheControl)
+	if ifNoneMatchHits(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data, info, err := h.Store.Get(key)
+	if errors.Is(err, blob.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "Image not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error reading image")
+		return
+	}
+	defer data.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Last-Modified", img.CreatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, data)
+}
+
+// deleteBlobs apaga o arquivo e a miniatura; arquivos que já não existem são ignorados
+func (h *ProductImageHandler) deleteBlobs(img *entity.ProductImage) error {
+	for _, key := range []string{img.BlobKey(), img.ThumbnailKey()} {
+		if err := h.Store.Delete(key); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// makeThumbnail decodifica a imagem e grava a miniatura no formato pedido
+func makeThumbnail(data []byte, contentType string) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	thumb := thumbnail.Fit(src, thumbnailSize)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
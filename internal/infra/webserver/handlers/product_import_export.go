@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// importBatchSize é quantos produtos válidos são gravados por transação
+	importBatchSize = 500
+	// exportBatchSize é quantos produtos são lidos do banco por vez durante a exportação
+	exportBatchSize = 500
+	// maxImportLineSize limita o tamanho de uma linha NDJSON
+	maxImportLineSize = 1 << 20
+)
+
+// productCSVColumns é o cabeçalho gerado pela exportação em CSV
+var productCSVColumns = []string{"id", "name", "price", "currency", "owner_id", "version", "created_at", "updated_at"}
+
+// csvFormulaPrefixes são os caracteres iniciais que fazem planilhas tratarem a célula como fórmula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// Import godoc
+// @Summary Import products
+// @Description Bulk create products from a CSV file (header with name, price and optional currency columns) or NDJSON (one {"name", "price"} object per line), chosen by Content-Type. Valid rows are saved in batched transactions; invalid rows are reported by line number. Bodies larger than MAX_IMPORT_SIZE bytes are rejected with 413; batches saved before the limit was reached are kept.
+// @Tags products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} dto.ProductImportOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 413 {object} Error
+// @Failure 415 {object} Error
+// @Router /products/import [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Import(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	importer := &productImporter{transactor: h.Transactor, ownerID: ownerID, defaultCurrency: h.DefaultCurrency}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxImportSize)
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "text/csv":
+		err = importer.readCSV(r.Body)
+	case "application/x-ndjson", "application/ndjson":
+		err = importer.readNDJSON(r.Body)
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		// O lote pendente é descartado; os já gravados ficam, como numa importação interrompida
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Import is larger than %d bytes; %d products saved before the limit were kept", h.MaxImportSize, importer.report.Imported))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	importer.flush()
+	if importer.report.Errors == nil {
+		importer.report.Errors = []dto.ProductImportError{}
+	}
+	writeJSON(w, http.StatusOK, importer.report)
+}
+
+// productImporter acumula os produtos válidos em lotes e monta o relatório por linha
+type productImporter struct {
+	transactor      database.TransactorInterface
+	ownerID         entitypkg.ID
+	defaultCurrency string
+	batch           []*entity.Product
+	lines           []int
+	report          dto.ProductImportOutput
+}
+
+func (imp *productImporter) add(line int, name string, priceInput dto.PriceInput) {
+	price, err := priceInput.Money(imp.defaultCurrency)
+	if err != nil {
+		imp.fail(line, err.Error())
+		return
+	}
+	product, err := entity.NewProduct(strings.TrimSpace(name), price)
+	if err != nil {
+		imp.fail(line, err.Error())
+		return
+	}
+	product.OwnerID = imp.ownerID
+
+	imp.batch = append(imp.batch, product)
+	imp.lines = append(imp.lines, line)
+	if len(imp.batch) >= importBatchSize {
+		imp.flush()
+	}
+}
+
+func (imp *productImporter) fail(line int, message string) {
+	imp.report.Failed++
+	imp.report.Errors = append(imp.report.Errors, dto.ProductImportError{Line: line, Error: message})
+}
+
+// flush grava o lote atual junto com as entradas de auditoria; se a transação falhar,
+// todas as linhas do lote são reportadas
+func (imp *productImporter) flush() {
+	if len(imp.batch) == 0 {
+		return
+	}
+	err := imp.transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.CreateBatch(imp.batch); err != nil {
+			return err
+		}
+		entries := make([]*entity.AuditEntry, len(imp.batch))
+		for i, product := range imp.batch {
+			entry, err := entity.NewAuditEntry(&imp.ownerID, entity.AuditCreate, entity.AuditProduct, product.ID, nil, product.AuditFields())
+			if err != nil {
+				return err
+			}
+			entries[i] = entry
+		}
+		return tx.Audit.Record(entries...)
+	})
+	if err != nil {
+		for _, line := range imp.lines {
+			imp.fail(line, "Error saving product")
+		}
+	} else {
+		imp.report.Imported += len(imp.batch)
+	}
+	imp.batch = imp.batch[:0]
+	imp.lines = imp.lines[:0]
+}
+
+// readCSV lê um CSV com cabeçalho. Só as colunas name, price e currency são usadas, então
+// um arquivo gerado pela exportação pode ser importado de volta.
+func (imp *productImporter) readCSV(body io.Reader) error {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	if err != nil {
+		return errors.New("CSV header is required")
+	}
+	nameCol, priceCol, currencyCol := -1, -1, -1
+	for i, column := range header {
+		// Planilhas costumam salvar o CSV com BOM no início
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		switch column {
+		case "name":
+			nameCol = i
+		case "price":
+			priceCol = i
+		case "currency":
+			currencyCol = i
+		}
+	}
+	if nameCol < 0 || priceCol < 0 {
+		return errors.New("CSV header must include name and price columns")
+	}
+
+	line, _ := reader.FieldPos(0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		if err != nil {
+			// Depois de um erro de sintaxe não dá para confiar no restante do arquivo.
+			// FieldPos só vale para registros lidos sem erro.
+			line++
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			imp.fail(line, "Invalid CSV: "+err.Error())
+			return nil
+		}
+		line, _ = reader.FieldPos(0)
+		if nameCol >= len(record) || priceCol >= len(record) {
+			imp.fail(line, "Missing name or price")
+			continue
+		}
+
+		// Sem a coluna currency (ou com ela vazia) vale a moeda padrão
+		price := dto.PriceInput{Amount: strings.TrimSpace(record[priceCol])}
+		if currencyCol >= 0 && currencyCol < len(record) {
+			price.Currency = strings.ToUpper(strings.TrimSpace(record[currencyCol]))
+		}
+		imp.add(line, unescapeCSVCell(record[nameCol]), price)
+	}
+}
+
+// readNDJSON lê um objeto JSON por linha; linhas em branco são ignoradas.
+// Só retorna erro quando o corpo passa do tamanho máximo.
+func (imp *productImporter) readNDJSON(body io.Reader) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var input dto.CreateProductInput
+		if err := json.Unmarshal([]byte(raw), &input); err != nil {
+			imp.fail(line, "Invalid JSON: "+err.Error())
+			continue
+		}
+		imp.add(line, input.Name, input.Price)
+	}
+	err := scanner.Err()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	if err != nil {
+		imp.fail(line+1, "Error reading line: "+err.Error())
+	}
+	return nil
+}
+
+// Export godoc
+// @Summary Export products
+// @Description Stream the whole catalog as CSV or NDJSON, ordered by creation date.
+// @Tags products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv (default) or ndjson"
+// @Success 200 {file} file
+// @Failure 400 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/export [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Export(w http.ResponseWriter, r *http.Request) {
+	for key := range r.URL.Query() {
+		if key != "format" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown query parameter: %s", key))
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		writeError(w, http.StatusBadRequest, "Invalid format: use csv or ndjson")
+		return
+	}
+
+	// Lê em lotes pelo cursor (created_at, id), sem carregar o catálogo inteiro.
+	// A primeira consulta acontece antes de qualquer byte ser enviado para ainda poder responder 500.
+	query := database.ProductQuery{Page: 1, Limit: exportBatchSize}
+	products, err := h.ProductDB.Search(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Erro ao buscar produtos")
+		return
+	}
+
+	var write func(entity.Product) error
+	var flush func() error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+		writer := csv.NewWriter(w)
+		writer.Write(productCSVColumns)
+		write = func(p entity.Product) error { return writer.Write(productCSVRecord(p)) }
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="products.ndjson"`)
+		encoder := json.NewEncoder(w)
+		write = func(p entity.Product) error { return encoder.Encode(p) }
+		flush = func() error { return nil }
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for {
+		for _, product := range products {
+			if err := write(product); err != nil {
+				log.Printf("erro ao exportar produtos: %v", err)
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			log.Printf("erro ao exportar produtos: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(products) < exportBatchSize {
+			return
+		}
+		query.After = database.NewProductCursor(products[len(products)-1])
+		// A resposta já começou; um erro aqui só pode interromper o arquivo
+		if products, err = h.ProductDB.Search(query); err != nil {
+			log.Printf("erro ao exportar produtos: %v", err)
+			return
+		}
+	}
+}
+
+func productCSVRecord(p entity.Product) []string {
+	return []string{
+		p.ID.String(),
+		escapeCSVCell(p.Name),
+		p.Price.Decimal(),
+		p.Price.Currency,
+		p.OwnerID.String(),
+		strconv.Itoa(p.Version),
+		p.CreatedAt.UTC().Format(time.RFC3339Nano),
+		p.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// escapeCSVCell impede que planilhas executem o texto como fórmula (CSV injection):
+// células que começam com =, +, -, @, tab ou CR ganham um apóstrofo na frente
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell desfaz escapeCSVCell, para que o arquivo exportado possa ser importado de volta
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
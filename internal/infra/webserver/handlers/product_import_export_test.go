@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"apis/db"
+	"apis/internal/dto"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newImportTestHandler monta o ProductHandler sobre um SQLite com o esquema das migrações
+func newImportTestHandler(t *testing.T, maxImportSize int64) (*ProductHandler, *gorm.DB) {
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "import.db")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrate(gormDB, db.Options{DefaultCurrency: "BRL"}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewProductHandler(database.NewProduct(gormDB), database.NewCategory(gormDB), database.NewTag(gormDB),
+		database.NewVariant(gormDB), database.NewTransactor(gormDB), "BRL", nil, maxImportSize)
+	return h, gormDB
+}
+
+// authenticated coloca na requisição o token que o jwtauth.Verifier colocaria
+func authenticated(t *testing.T, r *http.Request) *http.Request {
+	auth := jwtauth.New("HS256", []byte("secret"), nil)
+	token, _, err := auth.Encode(map[string]interface{}{"sub": entitypkg.NewID().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.WithContext(jwtauth.NewContext(r.Context(), token, nil))
+}
+
+func importProducts(t *testing.T, h *ProductHandler, contentType, body string) *httptest.ResponseRecorder {
+	r := authenticated(t, httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(body)))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	h.Import(w, r)
+	return w
+}
+
+func decodeImportReport(t *testing.T, w *httptest.ResponseRecorder) dto.ProductImportOutput {
+	var report dto.ProductImportOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	return report
+}
+
+func countProducts(t *testing.T, gormDB *gorm.DB) int64 {
+	var count int64
+	assert.NoError(t, gormDB.Table("products").Count(&count).Error)
+	return count
+}
+
+func TestImportExportCSVRoundTrip(t *testing.T) {
+	h, gormDB := newImportTestHandler(t, 1<<20)
+
+	w := importProducts(t, h, "text/csv", "name,price,currency\n=SUM(A1),10.50,BRL\n@cmd,3,USD\nPlain,1\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeImportReport(t, w)
+	assert.Equal(t, 3, report.Imported)
+	assert.Empty(t, report.Errors)
+
+	w = httptest.NewRecorder()
+	h.Export(w, authenticated(t, httptest.NewRequest(http.MethodGet, "/products/export?format=csv", nil)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	exported := w.Body.String()
+	records, err := csv.NewReader(strings.NewReader(exported)).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	names := map[string]bool{}
+	for _, record := range records[1:] {
+		names[record[1]] = true
+	}
+	// Nomes que planilhas executariam como fórmula saem escapados
+	assert.Equal(t, map[string]bool{"'=SUM(A1)": true, "'@cmd": true, "Plain": true}, names)
+
+	// O arquivo exportado volta com os nomes originais
+	assert.NoError(t, gormDB.Exec("DELETE FROM products").Error)
+	w = importProducts(t, h, "text/csv", exported)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, decodeImportReport(t, w).Imported)
+	var imported []string
+	assert.NoError(t, gormDB.Table("products").Order("name").Pluck("name", &imported).Error)
+	assert.Equal(t, []string{"=SUM(A1)", "@cmd", "Plain"}, imported)
+}
+
+func TestImportCSVMalformedQuote(t *testing.T) {
+	h, gormDB := newImportTestHandler(t, 1<<20)
+
+	w := importProducts(t, h, "text/csv", "name,price\nok,10\na\"b,10\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeImportReport(t, w)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Contains(t, report.Errors[0].Error, "Invalid CSV")
+	}
+	assert.Equal(t, int64(1), countProducts(t, gormDB))
+}
+
+func TestImportCSVHeader(t *testing.T) {
+	h, _ := newImportTestHandler(t, 1<<20)
+
+	w := importProducts(t, h, "text/csv", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "CSV header is required")
+
+	w = importProducts(t, h, "text/csv", "title,cost\nA,1\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "name and price")
+}
+
+func TestImportNDJSONLineTooLong(t *testing.T) {
+	h, gormDB := newImportTestHandler(t, 4<<20)
+
+	long := fmt.Sprintf(`{"name": %q, "price": 1}`, strings.Repeat("x", maxImportLineSize))
+	w := importProducts(t, h, "application/x-ndjson", "{\"name\": \"ok\", \"price\": 1}\n"+long+"\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeImportReport(t, w)
+	assert.Equal(t, 1, report.Imported)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 2, report.Errors[0].Line)
+		assert.Contains(t, report.Errors[0].Error, "Error reading line")
+	}
+	assert.Equal(t, int64(1), countProducts(t, gormDB))
+}
+
+func TestImportBodyTooLarge(t *testing.T) {
+	var csvBody, ndjsonBody strings.Builder
+	csvBody.WriteString("name,price\n")
+	for i := 0; i < importBatchSize+100; i++ {
+		fmt.Fprintf(&csvBody, "p%d,1\n", i)
+		fmt.Fprintf(&ndjsonBody, "{\"name\": \"p%d\", \"price\": 1}\n", i)
+	}
+
+	for contentType, body := range map[string]string{"text/csv": csvBody.String(), "application/x-ndjson": ndjsonBody.String()} {
+		// O limite cai depois do primeiro lote completo e antes do fim do arquivo
+		h, gormDB := newImportTestHandler(t, int64(len(body)-10))
+		w := importProducts(t, h, contentType, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, contentType)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf("%d products saved", importBatchSize), contentType)
+		// O lote gravado antes do limite fica; o pendente é descartado
+		assert.Equal(t, int64(importBatchSize), countProducts(t, gormDB), contentType)
+	}
+}
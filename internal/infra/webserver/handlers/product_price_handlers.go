@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"apis/internal/infra/exchange"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// SetPrice godoc
+// @Summary Set a product price in another currency
+// @Description Create or replace the explicit price of a product in a currency other than its base price currency. Explicit prices take precedence over converted ones in ?currency= reads.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param currency path string true "ISO 4217 currency code"
+// @Param price body dto.PriceInput true "Price; currency may be omitted and must match the path if sent"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/prices/{currency} [put]
+// @Security ApiKeyAuth
+func (h *ProductHandler) SetPrice(w http.ResponseWriter, r *http.Request) {
+	currency, err := parseCurrency(chi.URLParam(r, "currency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input dto.PriceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Currency != "" && input.Currency != currency {
+		writeError(w, http.StatusBadRequest, money.ErrCurrencyMismatch.Error())
+		return
+	}
+	price, err := input.Money(currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, ok := h.productForPriceChange(w, r)
+	if !ok {
+		return
+	}
+	before := product.AuditFields()
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.SetPrice(product, price); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, product.AuditFields())
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrBaseCurrency) || errors.Is(err, entity.ErrInvalidPrice) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.writePriceChangeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(product.Version))
+	writeJSON(w, http.StatusOK, product)
+}
+
+// DeletePrice godoc
+// @Summary Delete a product price in another currency
+// @Description Remove the explicit price of a product in a currency. Reads in that currency fall back to converting the base price.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param currency path string true "ISO 4217 currency code"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Success 204
+// @Header 204 {string} ETag "New product version"
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/prices/{currency} [delete]
+// @Security ApiKeyAuth
+func (h *ProductHandler) DeletePrice(w http.ResponseWriter, r *http.Request) {
+	currency, err := parseCurrency(chi.URLParam(r, "currency"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	product, ok := h.productForPriceChange(w, r)
+	if !ok {
+		return
+	}
+	before := product.AuditFields()
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.DeletePrice(product, currency); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, product.AuditFields())
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Price not found")
+			return
+		}
+		h.writePriceChangeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(product.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// productForPriceChange busca o produto e confere dono e If-Match.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func (h *ProductHandler) productForPriceChange(w http.ResponseWriter, r *http.Request) (*entity.Product, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return nil, false
+	}
+	product, err := h.ProductDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return nil, false
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can update this product")
+		return nil, false
+	}
+	if ifMatchFails(r, versionETag(product.Version)) {
+		writeError(w, http.StatusPreconditionFailed, "Product was modified by another request")
+		return nil, false
+	}
+	return product, true
+}
+
+func (h *ProductHandler) writePriceChangeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, database.ErrVersionConflict) {
+		writeVersionConflict(w, r)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "Error updating product prices")
+}
+
+// parseCurrency valida um código de moeda vindo da URL; minúsculas são aceitas
+func parseCurrency(value string) (string, error) {
+	currency := strings.ToUpper(strings.TrimSpace(value))
+	if !money.IsValidCurrency(currency) {
+		return "", money.ErrInvalidCurrency
+	}
+	return currency, nil
+}
+
+// parseCurrencyParam lê o parâmetro opcional ?currency=
+func parseCurrencyParam(r *http.Request) (string, error) {
+	value := r.URL.Query().Get("currency")
+	if value == "" {
+		return "", nil
+	}
+	return parseCurrency(value)
+}
+
+// localizePrice preenche product.LocalizedPrice na moeda pedida: o preço explícito, se houver,
+// ou o preço base convertido. Sem cotação disponível o campo fica ausente.
+func (h *ProductHandler) localizePrice(product *entity.Product, currency string) {
+	if price, ok := product.PriceIn(currency); ok {
+		product.LocalizedPrice = entity.NewLocalizedPrice(price, false)
+		return
+	}
+	price, err := exchange.Convert(h.Rates, product.Price, currency)
+	if err != nil {
+		return
+	}
+	product.LocalizedPrice = entity.NewLocalizedPrice(price, true)
+}
+
+func (h *ProductHandler) localizePrices(products []entity.Product, currency string) {
+	if currency == "" {
+		return
+	}
+	for i := range products {
+		h.localizePrice(&products[i], currency)
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// getAsOf responde GET /products/{id}?as_of=. A ETag fica de fora: ela só vale para a versão atual.
+func (p *ProductHandler) getAsOf(w http.ResponseWriter, id string, asOf time.Time, currency string) {
+	product, err := p.ProductDB.GetAsOf(id, asOf)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Product not found at as_of")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error fetching product revision")
+		return
+	}
+	if currency != "" {
+		p.localizePrice(product, currency)
+	}
+	writeJSON(w, http.StatusOK, product)
+}
+
+// Revisions godoc
+// @Summary List product revisions
+// @Description List the versioned snapshots of a product, newest first. Every change that increments the product version writes one.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} entity.ProductRevision
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/revisions [get]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Revisions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	if _, err := h.ProductDB.GetByID(id); err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	revisions, err := h.ProductDB.Revisions(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching revisions")
+		return
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+// Revert godoc
+// @Summary Revert a product to a revision
+// @Description Restore the name, prices, category and tags of a product from one of its revisions. The result is saved as a new version. Categories and tags removed since then make the revert fail with 409.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param revision body dto.RevertProductInput true "Version to restore"
+// @Param If-Match header string false "ETag from GET /products/{id}"
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 412 {object} Error
+// @Failure 422 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/revert [post]
+// @Security ApiKeyAuth
+func (h *ProductHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	var input dto.RevertProductInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if input.Version <= 0 {
+		writeError(w, http.StatusBadRequest, "version must be a positive integer")
+		return
+	}
+
+	// Mesmas verificações de dono e If-Match das alterações de preço
+	product, ok := h.productForPriceChange(w, r)
+	if !ok {
+		return
+	}
+	revision, err := h.ProductDB.GetRevision(product.ID.String(), input.Version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Revision not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error fetching revision")
+		return
+	}
+
+	before := product.AuditFields()
+	revision.Apply(product)
+
+	// A revisão guarda a categoria e as tags da época; elas precisam existir ainda
+	var categoryID *string
+	if product.CategoryID != nil {
+		id := product.CategoryID.String()
+		categoryID = &id
+	}
+	tagNames := make([]string, 0, len(product.Tags))
+	for _, tag := range product.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	if err := classifyProduct(h.CategoryDB, h.TagDB, product, categoryID, tagNames); err != nil {
+		writeClassificationError(w, http.StatusConflict, err)
+		return
+	}
+	if err := validateRevertedProduct(product); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Products.Revert(product); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, product.AuditFields())
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error reverting product")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(product.Version))
+	writeJSON(w, http.StatusOK, product)
+}
+
+// validateRevertedProduct aplica ao produto restaurado as mesmas regras da criação e de SetPrice
+func validateRevertedProduct(product *entity.Product) error {
+	if err := product.Validate(); err != nil {
+		return err
+	}
+	for _, price := range product.Prices {
+		if err := price.Validate(); err != nil {
+			return err
+		}
+		if price.Currency == product.Price.Currency {
+			return entity.ErrBaseCurrency
+		}
+	}
+	return nil
+}
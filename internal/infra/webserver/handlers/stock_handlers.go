@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// defaultReservationTTL é usado quando a reserva não informa ttl_seconds
+const defaultReservationTTL = 15 * time.Minute
+
+// StockHandler serve o estoque de produtos e, nas rotas /products/{id}/variants/{variantID}/stock,
+// o de variantes, que é controlado separadamente
+type StockHandler struct {
+	StockDB    database.StockInterface
+	ProductDB  database.ProductInterface
+	VariantDB  database.VariantInterface
+	Transactor database.TransactorInterface // grava a alteração e a entrada de auditoria do produto juntas
+}
+
+func NewStockHandler(stockDB database.StockInterface, productDB database.ProductInterface, variantDB database.VariantInterface, transactor database.TransactorInterface) *StockHandler {
+	return &StockHandler{
+		StockDB:    stockDB,
+		ProductDB:  productDB,
+		VariantDB:  variantDB,
+		Transactor: transactor,
+	}
+}
+
+// GetStock godoc
+// @Summary Get product stock
+// @Description Get the stock level of a product: units on hand, units held by active reservations and units available. Products without movements have zero stock. The same routes under /products/{id}/variants/{variantID}/stock manage the separate stock of a variant.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} dto.StockOutput
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock [get]
+// @Security ApiKeyAuth
+func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
+	_, itemID, ok := h.findStockItem(w, r)
+	if !ok {
+		return
+	}
+
+	level, err := h.StockDB.Level(itemID.String())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching stock")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStockOutput(level))
+}
+
+// Adjust godoc
+// @Summary Record a stock movement
+// @Description Append a receipt, sale or correction to the product stock ledger. Outgoing movements can only use available units (not reserved ones). Only the product owner or an admin can adjust stock.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param movement body dto.StockAdjustmentInput true "Movement; correction quantity is a signed delta"
+// @Success 201 {object} dto.StockOutput
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock/adjustments [post]
+// @Security ApiKeyAuth
+func (h *StockHandler) Adjust(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	var input dto.StockAdjustmentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	product, itemID, ok := h.findStockItem(w, r)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can adjust the stock of this product")
+		return
+	}
+
+	movement, err := entity.NewStockMovement(itemID, entity.StockMovementType(input.Type), input.Quantity, input.Note, userID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var level *entity.StockLevel
+	err = h.changeStock(r, product, itemID, func(stock database.StockInterface) error {
+		var err error
+		level, err = stock.Adjust(movement)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrInsufficientStock) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error adjusting stock")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newStockOutput(level))
+}
+
+// Movements godoc
+// @Summary List stock movements
+// @Description List the stock ledger of a product, newest first
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {array} entity.StockMovement
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock/movements [get]
+// @Security ApiKeyAuth
+func (h *StockHandler) Movements(w http.ResponseWriter, r *http.Request) {
+	_, itemID, ok := h.findStockItem(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		page = 0
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	movements, err := h.StockDB.Movements(itemID.String(), page, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching stock movements")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+// Reserve godoc
+// @Summary Reserve stock
+// @Description Hold units of a product until the reservation is committed, released or expires. ttl_seconds defaults to 900 and may be at most 86400.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param reservation body dto.StockReservationInput true "Reservation"
+// @Success 201 {object} entity.StockReservation
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock/reservations [post]
+// @Security ApiKeyAuth
+func (h *StockHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	var input dto.StockReservationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ttl := defaultReservationTTL
+	if input.TTLSeconds != 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+
+	product, itemID, ok := h.findStockItem(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := entity.NewStockReservation(itemID, input.Quantity, ttl, userID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.changeStock(r, product, itemID, func(stock database.StockInterface) error {
+		return stock.Reserve(reservation)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrInsufficientStock) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error reserving stock")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+// Release godoc
+// @Summary Release a stock reservation
+// @Description Return the units of an active reservation to the available stock. Only the user who created the reservation or an admin can release it.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param reservationID path string true "Reservation ID"
+// @Success 200 {object} entity.StockReservation
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock/reservations/{reservationID} [delete]
+// @Security ApiKeyAuth
+func (h *StockHandler) Release(w http.ResponseWriter, r *http.Request) {
+	product, reservation, ok := h.findReservation(w, r)
+	if !ok {
+		return
+	}
+
+	var released *entity.StockReservation
+	err := h.changeStock(r, product, reservation.ProductID, func(stock database.StockInterface) error {
+		var err error
+		released, err = stock.Release(reservation.ID.String())
+		return err
+	})
+	if err != nil {
+		writeReservationError(w, err, "Error releasing reservation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, released)
+}
+
+// Commit godoc
+// @Summary Commit a stock reservation
+// @Description Turn an active, unexpired reservation into a sale: the units leave the stock and a sale movement is recorded. Only the user who created the reservation or an admin can commit it.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param reservationID path string true "Reservation ID"
+// @Success 201 {object} entity.StockMovement
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/stock/reservations/{reservationID}/commit [post]
+// @Security ApiKeyAuth
+func (h *StockHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return
+	}
+
+	product, reservation, ok := h.findReservation(w, r)
+	if !ok {
+		return
+	}
+
+	var movement *entity.StockMovement
+	err = h.changeStock(r, product, reservation.ProductID, func(stock database.StockInterface) error {
+		var err error
+		movement, err = stock.Commit(reservation.ID.String(), userID)
+		return err
+	})
+	if err != nil {
+		writeReservationError(w, err, "Error committing reservation")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movement)
+}
+
+// changeStock executa fn numa transação e registra no histórico do produto a variação do saldo
+// de itemID (o produto ou uma variante dele). O saldo de antes já é lido sem as reservas expiradas,
+// então a expiração, feita pelo próprio sistema e registrada na reserva, não entra na entrada.
+func (h *StockHandler) changeStock(r *http.Request, product *entity.Product, itemID entitypkg.ID, fn func(stock database.StockInterface) error) error {
+	return h.Transactor.Transaction(func(tx *database.Repositories) error {
+		before, err := tx.Stock.Level(itemID.String())
+		if err != nil {
+			return err
+		}
+		if err := fn(tx.Stock); err != nil {
+			return err
+		}
+		after, err := tx.Stock.Level(itemID.String())
+		if err != nil {
+			return err
+		}
+		prefix := "stock"
+		if itemID != product.ID {
+			prefix = "variants." + itemID.String() + ".stock"
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before.AuditFields(prefix), after.AuditFields(prefix))
+	})
+}
+
+// findStockItem busca o produto da URL e, nas rotas de variante, a variante; itemID é o ID
+// sob o qual o estoque é guardado. Produtos na lixeira não têm estoque acessível.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func (h *StockHandler) findStockItem(w http.ResponseWriter, r *http.Request) (*entity.Product, entitypkg.ID, bool) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return nil, entitypkg.ID{}, false
+	}
+	if chi.URLParam(r, "variantID") == "" {
+		return product, product.ID, true
+	}
+	variant, ok := findVariant(w, r, h.VariantDB, product)
+	if !ok {
+		return nil, entitypkg.ID{}, false
+	}
+	return product, variant.ID, true
+}
+
+// findReservation busca o produto e a reserva da URL, que precisa ser do produto (ou variante)
+// da URL, e garante que o usuário do token a criou ou é admin
+func (h *StockHandler) findReservation(w http.ResponseWriter, r *http.Request) (*entity.Product, *entity.StockReservation, bool) {
+	product, itemID, ok := h.findStockItem(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id := chi.URLParam(r, "reservationID")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return nil, nil, false
+	}
+	reservation, err := h.StockDB.GetReservation(id)
+	if err != nil || reservation.ProductID != itemID {
+		writeError(w, http.StatusNotFound, "Reservation not found")
+		return nil, nil, false
+	}
+
+	if roleFromRequest(r) != entity.RoleAdmin {
+		userID, err := userIDFromRequest(r)
+		if err != nil || reservation.CreatedBy != userID {
+			writeError(w, http.StatusForbidden, "Only the creator can change this reservation")
+			return nil, nil, false
+		}
+	}
+	return product, reservation, true
+}
+
+func writeReservationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, http.StatusNotFound, "Reservation not found")
+	case errors.Is(err, database.ErrReservationNotActive), errors.Is(err, database.ErrInsufficientStock):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func newStockOutput(level *entity.StockLevel) dto.StockOutput {
+	return dto.StockOutput{
+		ProductID: level.ProductID.String(),
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+		UpdatedAt: level.UpdatedAt,
+	}
+}
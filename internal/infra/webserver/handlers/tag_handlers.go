@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type TagHandler struct {
+	TagDB      database.TagInterface
+	Transactor database.TransactorInterface // grava a alteração e a auditoria dos produtos afetados juntas
+}
+
+func NewTagHandler(db database.TagInterface, transactor database.TransactorInterface) *TagHandler {
+	return &TagHandler{TagDB: db, Transactor: transactor}
+}
+
+// Create Tag godoc
+// @Summary Create a tag
+// @Description Create a tag. Names are stored in lower case and must be unique.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body dto.TagInput true "Tag"
+// @Success 201 {object} entity.Tag
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /tags [post]
+// @Security ApiKeyAuth
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input dto.TagInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	tag, err := entity.NewTag(input.Name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.TagDB.Create(tag); err != nil {
+		if errors.Is(err, database.ErrTagExists) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error creating tag")
+		return
+	}
+	writeJSON(w, http.StatusCreated, tag)
+}
+
+// GetAll Tags godoc
+// @Summary List tags
+// @Description List all tags in alphabetical order
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Tag
+// @Failure 500 {object} Error
+// @Router /tags [get]
+// @Security ApiKeyAuth
+func (h *TagHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.TagDB.GetAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching tags")
+		return
+	}
+	if tags == nil {
+		tags = []entity.Tag{}
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// GetByID Tag godoc
+// @Summary Get a tag
+// @Description Get a tag by ID
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 200 {object} entity.Tag
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /tags/{id} [get]
+// @Security ApiKeyAuth
+func (h *TagHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+	tag, err := h.TagDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// Update Tag godoc
+// @Summary Rename a tag
+// @Description Rename a tag. Products keep the tag under its new name.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param tag body dto.TagInput true "Tag"
+// @Success 200 {object} entity.Tag
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /tags/{id} [put]
+// @Security ApiKeyAuth
+func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+	var input dto.TagInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tag, err := h.TagDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Tag not found")
+		return
+	}
+	tag.Name = entity.NormalizeTagName(input.Name)
+	if err := tag.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.TagDB.Update(tag); err != nil {
+		switch {
+		case errors.Is(err, database.ErrTagExists):
+			writeError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, http.StatusNotFound, "Tag not found")
+		default:
+			writeError(w, http.StatusInternalServerError, "Error updating tag")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// Delete Tag godoc
+// @Summary Delete a tag
+// @Description Delete a tag and remove it from every product
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /tags/{id} [delete]
+// @Security ApiKeyAuth
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+	// Os produtos que perdem a tag entram no log de auditoria
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		changes, err := tx.Tags.Delete(id)
+		if err != nil {
+			return err
+		}
+		return recordProductChanges(tx.Audit, r, changes)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error deleting tag")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
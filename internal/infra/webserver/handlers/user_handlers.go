@@ -4,10 +4,15 @@ import (
 	"apis/internal/dto"
 	"apis/internal/entity"
 	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 )
 
@@ -16,18 +21,30 @@ type Error struct {
 }
 
 type UserHandler struct {
-	UserDB database.UserInterface
+	UserDB           database.UserInterface
+	TokenDB          database.TokenInterface
+	Transactor       database.TransactorInterface // grava a alteração e a entrada de auditoria juntas
+	Jwt              *jwtauth.JWTAuth             // assina os access tokens
+	JwtExpiresIn     int                          // validade do access token, em segundos
+	DefaultRole      entity.Role
+	RefreshExpiresIn int
 }
 
-func NewUserHandler(db database.UserInterface, jwt *jwtauth.JWTAuth, jwtExpiresIn int) *UserHandler {
+func NewUserHandler(db database.UserInterface, tokenDB database.TokenInterface, transactor database.TransactorInterface, jwt *jwtauth.JWTAuth, jwtExpiresIn int, refreshExpiresIn int, defaultRole entity.Role) *UserHandler {
 	return &UserHandler{
-		UserDB: db,
+		UserDB:           db,
+		TokenDB:          tokenDB,
+		Transactor:       transactor,
+		Jwt:              jwt,
+		JwtExpiresIn:     jwtExpiresIn,
+		DefaultRole:      defaultRole,
+		RefreshExpiresIn: refreshExpiresIn,
 	}
 }
 
 // GenerateJWT godoc
 // @Summary Generate JWT
-// @Description Get a user JWT
+// @Description Get a user JWT and a refresh token
 // @Tags users
 // @Accept json
 // @Produce json
@@ -37,50 +54,160 @@ func NewUserHandler(db database.UserInterface, jwt *jwtauth.JWTAuth, jwtExpiresI
 // @Failure 500 {object} Error
 // @Router /users/login [post]
 func (h *UserHandler) GenerateJWT(w http.ResponseWriter, r *http.Request) {
-	jwt, ok := r.Context().Value("jwt").(*jwtauth.JWTAuth)
-	if !ok || jwt == nil {
-		writeError(w, http.StatusUnauthorized, "JWT middleware not configured")
+	var input dto.GetJWTInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	jwtExpiresIn, ok := r.Context().Value("jwtExpiresIn").(int)
+	user, err := h.UserDB.GetByEmail(input.Email)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := user.ComparePassword(input.Password); err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid password")
+		return
+	}
+
+	accessToken, ok := h.signAccessToken(w, user)
 	if !ok {
-		writeError(w, http.StatusInternalServerError, "JWT expiration config missing")
 		return
 	}
 
-	var input dto.GetJWTInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	refreshToken, plain, err := entity.NewRefreshToken(user.ID, time.Second*time.Duration(h.RefreshExpiresIn))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error generating refresh token")
+		return
+	}
+	if err := h.TokenDB.CreateRefreshToken(refreshToken); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error saving refresh token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.AccessTokenOutput{AccessToken: accessToken, RefreshToken: plain})
+}
+
+// Refresh godoc
+// @Summary Refresh JWT
+// @Description Exchange a refresh token for a new JWT. The refresh token is rotated and cannot be used again.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body dto.RefreshTokenInput true "Refresh token"
+// @Success 200 {object} dto.AccessTokenOutput
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/refresh [post]
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var input dto.RefreshTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.RefreshToken == "" {
 		writeError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	user, err := h.UserDB.GetByEmail(input.Email)
+	stored, err := h.TokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(input.RefreshToken))
 	if err != nil {
-		writeError(w, http.StatusNotFound, "User not found")
+		writeError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+	if err := stored.Validate(); err != nil {
+		if errors.Is(err, entity.ErrRefreshTokenRevoked) {
+			// Reuso de um token já rotacionado indica vazamento: encerra todas as sessões do usuário.
+			// Se isso falhar os outros refresh tokens continuam valendo, então não dá para seguir.
+			if err := h.TokenDB.RevokeAllRefreshTokens(stored.UserID.String()); err != nil {
+				log.Printf("erro ao revogar as sessões do usuário %s após reuso de refresh token: %v", stored.UserID, err)
+				writeError(w, http.StatusInternalServerError, "Error revoking sessions")
+				return
+			}
+		}
+		writeError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 
-	if err := user.ComparePassword(input.Password); err != nil {
-		writeError(w, http.StatusUnauthorized, "Invalid password")
+	user, err := h.UserDB.GetByID(stored.UserID.String())
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "User not found")
 		return
 	}
 
-	_, tokenString, _ := jwt.Encode(map[string]interface{}{
-		"sub": user.ID.String(),
-		"exp": time.Now().Add(time.Second * time.Duration(jwtExpiresIn)).Unix(),
-	})
+	refreshToken, plain, err := entity.NewRefreshToken(user.ID, time.Second*time.Duration(h.RefreshExpiresIn))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error generating refresh token")
+		return
+	}
+	if err := h.TokenDB.RotateRefreshToken(stored, refreshToken); err != nil {
+		if errors.Is(err, entity.ErrRefreshTokenRevoked) {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error rotating refresh token")
+		return
+	}
+
+	accessToken, ok := h.signAccessToken(w, user)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.AccessTokenOutput{AccessToken: accessToken, RefreshToken: plain})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current JWT and, when informed, the refresh token
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param token body dto.RefreshTokenInput false "Refresh token"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/logout [post]
+// @Security ApiKeyAuth
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, _, err := jwtauth.FromContext(r.Context())
+	if err != nil || token == nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
 
-	writeJSON(w, http.StatusOK, dto.AccessTokenOutput{AccessToken: tokenString})
+	var input dto.RefreshTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.TokenDB.RevokeAccessToken(token.JwtID(), token.Expiration()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error revoking token")
+		return
+	}
+
+	if input.RefreshToken != "" {
+		stored, err := h.TokenDB.GetRefreshTokenByHash(entity.HashRefreshToken(input.RefreshToken))
+		// Só revoga refresh tokens do próprio usuário do JWT
+		if err == nil && stored.UserID.String() == token.Subject() {
+			if err := h.TokenDB.RevokeRefreshToken(stored.ID.String()); err != nil {
+				writeError(w, http.StatusInternalServerError, "Error revoking refresh token")
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // @Summary Create a new user
-// @Description Create a new user
+// @Description Create a new user. Emails are stored in lower case and must be unique; a registered email returns 409.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body dto.CreateUserInput true "User"
 // @Success 201 {object} dto.CreateUserInput
+// @Failure 409 {object} Error
 // @Failure 500 {object} Error
 // @Router /users [post]
 func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -98,14 +225,291 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(error)
 		return
 	}
-	err = h.UserDB.Create(u)
+	if h.DefaultRole != "" {
+		if err := u.SetRole(h.DefaultRole); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Users.Create(u); err != nil {
+			return err
+		}
+		entry, err := newAuditEntry(r, entity.AuditCreate, entity.AuditUser, u.ID, nil, u.AuditFields())
+		if err != nil {
+			return err
+		}
+		// No cadastro ainda não há token: o autor é o próprio usuário
+		entry.ActorID = &u.ID
+		return tx.Audit.Record(entry)
+	})
 	if err != nil {
+		if errors.Is(err, database.ErrEmailExists) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusCreated)
 }
 
+// GetMe godoc
+// @Summary Get current user
+// @Description Get the account of the authenticated user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} entity.User
+// @Failure 401 {object} Error
+// @Failure 404 {object} Error
+// @Router /users/me [get]
+// @Security ApiKeyAuth
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description Update name and/or email of the authenticated user. Omitted fields are kept.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body dto.UpdateUserInput true "User"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/me [patch]
+// @Security ApiKeyAuth
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateUserInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	before := user.AuditFields()
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+	if input.Email != nil {
+		user.Email = entity.NormalizeEmail(*input.Email)
+	}
+	if err := user.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Users.Update(user); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditUser, user.ID, before, user.AuditFields())
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrEmailExists) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Error updating user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the password of the authenticated user. All refresh tokens are revoked.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param password body dto.ChangePasswordInput true "Passwords"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 401 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/me/password [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.ChangePasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := user.ChangePassword(input.CurrentPassword, input.NewPassword); err != nil {
+		if errors.Is(err, entity.ErrInvalidPassword) {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Users.Update(user); err != nil {
+			return err
+		}
+		entry, err := newAuditEntry(r, entity.AuditUpdate, entity.AuditUser, user.ID, nil, nil)
+		if err != nil {
+			return err
+		}
+		// A senha entra no log sem os valores
+		entry.Changes = append(entry.Changes, entity.FieldChange{Field: "password"})
+		return tx.Audit.Record(entry)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error updating password")
+		return
+	}
+
+	// Sessões abertas com a senha antiga não podem mais ser renovadas
+	if err := h.TokenDB.RevokeAllRefreshTokens(user.ID.String()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error revoking refresh tokens")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteMe godoc
+// @Summary Delete current user
+// @Description Delete the account of the authenticated user and revoke its tokens
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 401 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/me [delete]
+// @Security ApiKeyAuth
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Users.Delete(user.ID.String()); err != nil {
+			return err
+		}
+		// A conta é removida de vez, então a entrada guarda os últimos dados dela
+		return recordAudit(tx.Audit, r, entity.AuditDelete, entity.AuditUser, user.ID, user.AuditFields(), nil)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error deleting user")
+		return
+	}
+
+	if err := h.TokenDB.RevokeAllRefreshTokens(user.ID.String()); err != nil {
+		writeError(w, http.StatusInternalServerError, "Error revoking refresh tokens")
+		return
+	}
+	token, _, err := jwtauth.FromContext(r.Context())
+	if err == nil && token != nil {
+		if err := h.TokenDB.RevokeAccessToken(token.JwtID(), token.Expiration()); err != nil {
+			writeError(w, http.StatusInternalServerError, "Error revoking token")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetRole godoc
+// @Summary Change the role of a user
+// @Description Set the role (viewer, editor or admin) of another user. Admin only; admins cannot change their own role, so there is always at least one admin. The new role is in the tokens issued from the next login or refresh.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role body dto.SetRoleInput true "Role"
+// @Success 200 {object} entity.User
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /users/{id}/role [put]
+// @Security ApiKeyAuth
+func (h *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID, err := entitypkg.ParseID(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	var input dto.SetRoleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if actorID, err := userIDFromRequest(r); err != nil || actorID == userID {
+		writeError(w, http.StatusBadRequest, "Admins cannot change their own role")
+		return
+	}
+
+	user, err := h.UserDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	before := user.AuditFields()
+	if err := user.SetRole(entity.Role(input.Role)); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Users.Update(user); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditUser, user.ID, before, user.AuditFields())
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error updating user")
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// currentUser carrega o usuário dono do token da requisição
+func (h *UserHandler) currentUser(w http.ResponseWriter, r *http.Request) (*entity.User, bool) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "Invalid token subject")
+		return nil, false
+	}
+
+	user, err := h.UserDB.GetByID(userID.String())
+	if err != nil {
+		writeError(w, http.StatusNotFound, "User not found")
+		return nil, false
+	}
+
+	return user, true
+}
+
 func writeError(w http.ResponseWriter, status int, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -117,3 +521,39 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// userIDFromRequest extrai o ID do usuário do claim "sub" do token validado pelo jwtauth
+func userIDFromRequest(r *http.Request) (entitypkg.ID, error) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return entitypkg.ID{}, err
+	}
+	sub, _ := claims["sub"].(string)
+	return entitypkg.ParseID(sub)
+}
+
+// signAccessToken gera o JWT do usuário com a chave e a validade do handler
+func (h *UserHandler) signAccessToken(w http.ResponseWriter, user *entity.User) (string, bool) {
+	_, tokenString, err := h.Jwt.Encode(map[string]interface{}{
+		"sub":  user.ID.String(),
+		"role": string(user.Role),
+		"jti":  entitypkg.NewID().String(),
+		"exp":  time.Now().Add(time.Second * time.Duration(h.JwtExpiresIn)).Unix(),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error generating token")
+		return "", false
+	}
+
+	return tokenString, true
+}
+
+// roleFromRequest retorna o papel informado no claim "role" do token
+func roleFromRequest(r *http.Request) entity.Role {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	return entity.Role(role)
+}
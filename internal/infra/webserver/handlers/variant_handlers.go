@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/money"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type VariantHandler struct {
+	VariantDB  database.VariantInterface
+	ProductDB  database.ProductInterface
+	Transactor database.TransactorInterface // grava a alteração e a entrada de auditoria do produto juntas
+}
+
+func NewVariantHandler(variantDB database.VariantInterface, productDB database.ProductInterface, transactor database.TransactorInterface) *VariantHandler {
+	return &VariantHandler{
+		VariantDB:  variantDB,
+		ProductDB:  productDB,
+		Transactor: transactor,
+	}
+}
+
+// Create Variant godoc
+// @Summary Create a product variant
+// @Description Create a variant (size, color...) of a product. SKUs are stored in upper case and must be unique; two variants of a product cannot have the same options. Only the product owner or an admin can manage variants.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant body dto.VariantInput true "Variant; without price the product price is used"
+// @Success 201 {object} entity.Variant
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/variants [post]
+// @Security ApiKeyAuth
+func (h *VariantHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input dto.VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can manage the variants of this product")
+		return
+	}
+
+	price, err := variantPrice(input.Price, product)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	variant, err := entity.NewVariant(product.ID, input.SKU, input.Options, price)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// As variantes fazem parte do produto e entram no histórico dele
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Variants.Create(variant); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, nil, variant.AuditFields())
+	})
+	if err != nil {
+		writeVariantError(w, err, "Error creating variant")
+		return
+	}
+	writeJSON(w, http.StatusCreated, variant)
+}
+
+// GetAll Variants godoc
+// @Summary List product variants
+// @Description List the variants of a product, ordered by SKU
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} entity.Variant
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/variants [get]
+// @Security ApiKeyAuth
+func (h *VariantHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	variants, err := h.VariantDB.GetByProduct(product.ID.String())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Error fetching variants")
+		return
+	}
+	writeJSON(w, http.StatusOK, variants)
+}
+
+// GetByID Variant godoc
+// @Summary Get a product variant
+// @Description Get a variant of a product by ID
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantID path string true "Variant ID"
+// @Success 200 {object} entity.Variant
+// @Failure 400 {object} Error
+// @Failure 404 {object} Error
+// @Router /products/{id}/variants/{variantID} [get]
+// @Security ApiKeyAuth
+func (h *VariantHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	variant, ok := findVariant(w, r, h.VariantDB, product)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, variant)
+}
+
+// Update Variant godoc
+// @Summary Update a product variant
+// @Description Replace the SKU, options and price of a variant. A missing price makes the variant use the product price again.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantID path string true "Variant ID"
+// @Param variant body dto.VariantInput true "Variant"
+// @Success 200 {object} entity.Variant
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 409 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/variants/{variantID} [put]
+// @Security ApiKeyAuth
+func (h *VariantHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var input dto.VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can manage the variants of this product")
+		return
+	}
+	variant, ok := findVariant(w, r, h.VariantDB, product)
+	if !ok {
+		return
+	}
+
+	price, err := variantPrice(input.Price, product)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before := variant.AuditFields()
+	variant.Set(input.SKU, input.Options, price)
+	if err := variant.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Variants.Update(variant); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, before, variant.AuditFields())
+	})
+	if err != nil {
+		writeVariantError(w, err, "Error updating variant")
+		return
+	}
+	writeJSON(w, http.StatusOK, variant)
+}
+
+// Delete Variant godoc
+// @Summary Delete a product variant
+// @Description Delete a variant together with its stock
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantID path string true "Variant ID"
+// @Success 204
+// @Failure 400 {object} Error
+// @Failure 403 {object} Error
+// @Failure 404 {object} Error
+// @Failure 500 {object} Error
+// @Router /products/{id}/variants/{variantID} [delete]
+// @Security ApiKeyAuth
+func (h *VariantHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	product, ok := productFromURL(w, r, h.ProductDB)
+	if !ok {
+		return
+	}
+	if !canModifyProduct(r, product) {
+		writeError(w, http.StatusForbidden, "Only the owner can manage the variants of this product")
+		return
+	}
+	variant, ok := findVariant(w, r, h.VariantDB, product)
+	if !ok {
+		return
+	}
+
+	err := h.Transactor.Transaction(func(tx *database.Repositories) error {
+		if err := tx.Variants.Delete(variant.ID.String()); err != nil {
+			return err
+		}
+		return recordAudit(tx.Audit, r, entity.AuditUpdate, entity.AuditProduct, product.ID, variant.AuditFields(), nil)
+	})
+	if err != nil {
+		writeVariantError(w, err, "Error deleting variant")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// productFromURL busca o produto da URL, para as rotas aninhadas em /products/{id}.
+// Produtos na lixeira são tratados como inexistentes.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func productFromURL(w http.ResponseWriter, r *http.Request, productDB database.ProductInterface) (*entity.Product, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid product ID")
+		return nil, false
+	}
+	product, err := productDB.GetByID(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Product not found")
+		return nil, false
+	}
+	return product, true
+}
+
+// findVariant busca a variante da URL, que precisa ser do produto informado
+func findVariant(w http.ResponseWriter, r *http.Request, variantDB database.VariantInterface, product *entity.Product) (*entity.Variant, bool) {
+	id := chi.URLParam(r, "variantID")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid variant ID")
+		return nil, false
+	}
+	variant, err := variantDB.GetByID(id)
+	if err != nil || variant.ProductID != product.ID {
+		writeError(w, http.StatusNotFound, "Variant not found")
+		return nil, false
+	}
+	return variant, true
+}
+
+// variantPrice converte o preço opcional da variante; sem currency vale a moeda do produto
+func variantPrice(input *dto.PriceInput, product *entity.Product) (*money.Money, error) {
+	if input == nil {
+		return nil, nil
+	}
+	price, err := input.Money(product.Price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func writeVariantError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, database.ErrSKUExists), errors.Is(err, database.ErrVariantOptionsExists):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, http.StatusNotFound, "Variant not found")
+	default:
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
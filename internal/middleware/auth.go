@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"encoding/json"
 	"net/http"
 
-	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
 )
 
@@ -14,3 +17,68 @@ func ProtectedRoutes(tokenAuth *jwtauth.JWTAuth, register func(r chi.Router)) ht
 	register(r)
 	return r
 }
+
+// RequireRole libera a rota apenas quando o claim "role" do token está entre os papéis informados.
+// Deve ser usado depois do jwtauth.Verifier/Authenticator.
+func RequireRole(roles ...entity.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				forbidden(w)
+				return
+			}
+
+			role, _ := claims["role"].(string)
+			for _, allowed := range roles {
+				if entity.Role(role) == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			forbidden(w)
+		})
+	}
+}
+
+// RejectRevokedTokens consulta o claim "jti" na denylist e recusa tokens revogados no logout.
+// Deve ser usado depois do jwtauth.Verifier/Authenticator.
+func RejectRevokedTokens(tokenDB database.TokenInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _, err := jwtauth.FromContext(r.Context())
+			if err != nil || token == nil || token.JwtID() == "" {
+				writeError(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+
+			revoked, err := tokenDB.IsAccessTokenRevoked(token.JwtID())
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "Error checking token")
+				return
+			}
+			if revoked {
+				writeError(w, http.StatusUnauthorized, "Token revoked")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forbidden(w http.ResponseWriter) {
+	writeError(w, http.StatusForbidden, "Insufficient permissions")
+}
+
+// errorResponse tem o mesmo formato do Error dos handlers, sem que o middleware dependa deles
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Message: msg})
+}
@@ -0,0 +1,303 @@
+// Package jsonpatch aplica JSON Merge Patch (RFC 7396) e JSON Patch (RFC 6902)
+// sobre documentos JSON.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("Invalid patch document")
+	ErrPathNotFound = errors.New("Patch path not found")
+	ErrTestFailed   = errors.New("Patch test operation failed")
+)
+
+// MergePatch aplica um JSON Merge Patch: membros com null são removidos,
+// objetos são mesclados recursivamente e qualquer outro valor substitui o atual.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+// Operation é uma operação de um JSON Patch. Path e From são ponteiros para
+// diferenciar membros ausentes de "", e Value fica vazio quando não é enviado.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply aplica um JSON Patch. As operações rodam em ordem e, se alguma falhar,
+// nenhuma alteração é devolvida.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = op.apply(target)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalidPatch)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, err
+			}
+			return set(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s", ErrTestFailed, *op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrInvalidPatch)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(doc, path, clone(value))
+		}
+		// Um valor não pode ser movido para dentro dele mesmo
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPatch, *op.From)
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer separa um JSON Pointer (RFC 6901) em tokens. "" aponta para o documento inteiro.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	current := doc
+	for _, token := range path {
+		switch c := current.(type) {
+		case map[string]any:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, token)
+			}
+			current = value
+		case []any:
+			i, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = c[i]
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, token)
+		}
+	}
+	return current, nil
+}
+
+// set troca o valor em path, que precisa existir, e devolve o documento atualizado
+func set(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch c := parent.(type) {
+	case map[string]any:
+		c[key] = value
+	case []any:
+		i, err := arrayIndex(key, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = value
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, key)
+	}
+	return doc, nil
+}
+
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch c := parent.(type) {
+	case map[string]any:
+		c[key] = value
+		return doc, nil
+	case []any:
+		i := len(c)
+		if key != "-" {
+			if i, err = arrayIndex(key, len(c)); err != nil {
+				return nil, err
+			}
+		}
+		items := make([]any, 0, len(c)+1)
+		items = append(items, c[:i]...)
+		items = append(items, value)
+		items = append(items, c[i:]...)
+		// O slice mudou de tamanho, então precisa ser regravado no pai
+		return set(doc, path[:len(path)-1], items)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrPathNotFound, key)
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch c := parent.(type) {
+	case map[string]any:
+		if _, ok := c[key]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, key)
+		}
+		delete(c, key)
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(key, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]any, 0, len(c)-1)
+		items = append(items, c[:i]...)
+		items = append(items, c[i+1:]...)
+		return set(doc, path[:len(path)-1], items)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrPathNotFound, key)
+}
+
+// arrayIndex converte um token em índice entre 0 e max, sem zeros à esquerda
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("%w: %s", ErrPathNotFound, token)
+	}
+	return i, nil
+}
+
+// clone faz uma cópia profunda de um valor JSON decodificado
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = clone(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = clone(item)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,85 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := `{"a":"b","c":{"d":"e","f":"g"}}`
+
+	got, err := MergePatch([]byte(doc), []byte(`{"a":"z","c":{"f":null}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":"z","c":{"d":"e"}}`, string(got))
+
+	// Valores que não são objetos substituem o documento inteiro
+	got, err = MergePatch([]byte(doc), []byte(`["x"]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["x"]`, string(got))
+
+	// Objetos aninhados são criados quando não existem
+	got, err = MergePatch([]byte(`{"a":"b"}`), []byte(`{"x":{"y":null,"z":1}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":"b","x":{"z":1}}`, string(got))
+
+	_, err = MergePatch([]byte(doc), []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"name":"Notebook","price":10,"tags":["a","b"]}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"replace", `[{"op":"replace","path":"/price","value":20}]`, `{"name":"Notebook","price":20,"tags":["a","b"]}`},
+		{"add member", `[{"op":"add","path":"/color","value":"red"}]`, `{"name":"Notebook","price":10,"tags":["a","b"],"color":"red"}`},
+		{"add to array", `[{"op":"add","path":"/tags/1","value":"x"}]`, `{"name":"Notebook","price":10,"tags":["a","x","b"]}`},
+		{"append to array", `[{"op":"add","path":"/tags/-","value":"c"}]`, `{"name":"Notebook","price":10,"tags":["a","b","c"]}`},
+		{"remove", `[{"op":"remove","path":"/tags/0"}]`, `{"name":"Notebook","price":10,"tags":["b"]}`},
+		{"move", `[{"op":"move","from":"/name","path":"/title"}]`, `{"title":"Notebook","price":10,"tags":["a","b"]}`},
+		{"copy", `[{"op":"copy","from":"/tags","path":"/labels"}]`, `{"name":"Notebook","price":10,"tags":["a","b"],"labels":["a","b"]}`},
+		{"test then replace", `[{"op":"test","path":"/price","value":10},{"op":"replace","path":"/price","value":15}]`, `{"name":"Notebook","price":15,"tags":["a","b"]}`},
+		{"escaped pointer", `[{"op":"add","path":"/a~1b~0c","value":1}]`, `{"name":"Notebook","price":10,"tags":["a","b"],"a/b~c":1}`},
+		{"null value", `[{"op":"replace","path":"/name","value":null}]`, `{"name":null,"price":10,"tags":["a","b"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(doc), []byte(tt.patch))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := `{"name":"Notebook","price":10,"tags":["a","b"]}`
+
+	tests := []struct {
+		name  string
+		patch string
+		err   error
+	}{
+		{"malformed", `{"op":"add"}`, ErrInvalidPatch},
+		{"unknown op", `[{"op":"merge","path":"/name","value":"x"}]`, ErrInvalidPatch},
+		{"missing value", `[{"op":"replace","path":"/name"}]`, ErrInvalidPatch},
+		{"missing path", `[{"op":"remove"}]`, ErrInvalidPatch},
+		{"relative path", `[{"op":"remove","path":"name"}]`, ErrInvalidPatch},
+		{"leading zero index", `[{"op":"remove","path":"/tags/01"}]`, ErrInvalidPatch},
+		{"move into itself", `[{"op":"move","from":"/tags","path":"/tags/0"}]`, ErrInvalidPatch},
+		{"replace missing member", `[{"op":"replace","path":"/color","value":"red"}]`, ErrPathNotFound},
+		{"remove missing member", `[{"op":"remove","path":"/color"}]`, ErrPathNotFound},
+		{"index out of range", `[{"op":"add","path":"/tags/5","value":"x"}]`, ErrPathNotFound},
+		{"missing parent", `[{"op":"add","path":"/a/b","value":1}]`, ErrPathNotFound},
+		{"test failed", `[{"op":"test","path":"/price","value":11}]`, ErrTestFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(doc), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
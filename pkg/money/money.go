@@ -0,0 +1,187 @@
+// Package money representa valores monetários como inteiros em unidades menores
+// (centavos, por exemplo) junto com o código ISO 4217 da moeda.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrency  = errors.New("Invalid currency")
+	ErrInvalidAmount    = errors.New("Invalid amount")
+	ErrTooManyDecimals  = errors.New("Amount has more decimal places than the currency allows")
+	ErrCurrencyMismatch = errors.New("Currency mismatch")
+	ErrAmountOverflow   = errors.New("Amount out of range")
+)
+
+// currencyDecimals são as moedas aceitas e quantas casas decimais cada uma usa (ISO 4217)
+var currencyDecimals = map[string]int{
+	"ARS": 2, "AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "COP": 2,
+	"EUR": 2, "GBP": 2, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "PYG": 0, "USD": 2,
+	"UYU": 2,
+}
+
+// Money é um valor exato: Amount está em unidades menores da moeda (1499.99 BRL = 149999).
+// Em JSON o valor é escrito como string decimal: {"amount": "1499.99", "currency": "BRL"}.
+type Money struct {
+	Amount   int64  `json:"amount" swaggertype:"string" example:"1499.99"`
+	Currency string `json:"currency" example:"BRL"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// IsValidCurrency informa se o código ISO 4217 é suportado
+func IsValidCurrency(currency string) bool {
+	_, ok := currencyDecimals[currency]
+	return ok
+}
+
+// Decimals retorna quantas casas decimais a moeda usa
+func Decimals(currency string) (int, error) {
+	decimals, ok := currencyDecimals[currency]
+	if !ok {
+		return 0, ErrInvalidCurrency
+	}
+	return decimals, nil
+}
+
+// decimalPattern aceita só notação decimal simples, sem expoente
+var decimalPattern = regexp.MustCompile(`^-?[0-9]{1,19}(\.[0-9]{1,19})?$`)
+
+// Parse lê um valor decimal exato, como "1499.99". Casas decimais além das que a
+// moeda permite são rejeitadas em vez de arredondadas.
+func Parse(amount, currency string) (Money, error) {
+	decimals, err := Decimals(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	amount = strings.TrimSpace(amount)
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, ErrInvalidAmount
+	}
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !value.IsInt() {
+		return Money{}, ErrTooManyDecimals
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{Amount: value.Num().Int64(), Currency: currency}, nil
+}
+
+// FromFloat converte um valor float, arredondando para a unidade menor mais próxima.
+// Serve só para dados legados; valores novos devem usar Parse.
+func FromFloat(amount float64, currency string) (Money, error) {
+	decimals, err := Decimals(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	minor := math.Round(amount * math.Pow10(decimals))
+	if math.IsNaN(minor) || math.IsInf(minor, 0) || math.Abs(minor) >= math.MaxInt64 {
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{Amount: int64(minor), Currency: currency}, nil
+}
+
+// Validate verifica se a moeda é suportada
+func (m Money) Validate() error {
+	if !IsValidCurrency(m.Currency) {
+		return ErrInvalidCurrency
+	}
+	return nil
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Add soma dois valores da mesma moeda. Uma soma fora do int64 é um erro, não um valor truncado.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul multiplica o valor por uma quantidade inteira, com o mesmo limite de Add
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(quantity))
+	if !product.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: product.Int64(), Currency: m.Currency}, nil
+}
+
+// Cmp compara dois valores da mesma moeda: -1 se m < other, 0 se iguais e 1 se m > other
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Currency != other.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal formata o valor com as casas decimais da moeda, por exemplo "1499.99"
+func (m Money) Decimal() string {
+	decimals := currencyDecimals[m.Currency]
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	abs := new(big.Int).Abs(big.NewInt(amount)).String()
+	if decimals == 0 {
+		return sign + abs
+	}
+	if len(abs) <= decimals {
+		abs = strings.Repeat("0", decimals-len(abs)+1) + abs
+	}
+	return sign + abs[:len(abs)-decimals] + "." + abs[len(abs)-decimals:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	parsed, err := Parse(raw.Amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,114 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse("1499.99", "BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, New(149999, "BRL"), m)
+
+	m, err = Parse("10", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), m.Amount)
+
+	m, err = Parse("-2.5", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-250), m.Amount)
+
+	_, err = Parse("1e3", "JPY")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	m, err = Parse("1.005", "KWD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1005), m.Amount)
+
+	_, err = Parse("1.999", "BRL")
+	assert.ErrorIs(t, err, ErrTooManyDecimals)
+
+	_, err = Parse("abc", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = Parse("10", "XXX")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+
+	_, err = Parse("9999999999999999999", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestFromFloat(t *testing.T) {
+	// 1499.99 não tem representação exata em float64
+	m, err := FromFloat(1499.99, "BRL")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(149999), m.Amount)
+
+	m, err = FromFloat(0.1+0.2, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), m.Amount)
+
+	_, err = FromFloat(10, "")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestDecimal(t *testing.T) {
+	assert.Equal(t, "1499.99", New(149999, "BRL").Decimal())
+	assert.Equal(t, "0.05", New(5, "USD").Decimal())
+	assert.Equal(t, "-0.50", New(-50, "EUR").Decimal())
+	assert.Equal(t, "1500", New(1500, "JPY").Decimal())
+	assert.Equal(t, "1.005", New(1005, "KWD").Decimal())
+	assert.Equal(t, "10.00 BRL", New(1000, "BRL").String())
+}
+
+func TestArithmetic(t *testing.T) {
+	total, err := New(149999, "BRL").Add(New(1, "BRL"))
+	assert.NoError(t, err)
+	assert.Equal(t, New(150000, "BRL"), total)
+	product, err := New(149999, "BRL").Mul(3)
+	assert.NoError(t, err)
+	assert.Equal(t, New(449997, "BRL"), product)
+
+	_, err = New(100, "BRL").Add(New(100, "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	cmp, err := New(100, "BRL").Cmp(New(200, "BRL"))
+	assert.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+	_, err = New(100, "BRL").Cmp(New(100, "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	_, err := New(math.MaxInt64, "BRL").Add(New(1, "BRL"))
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = New(math.MinInt64, "BRL").Add(New(-1, "BRL"))
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	total, err := New(math.MaxInt64, "BRL").Add(New(-1, "BRL"))
+	assert.NoError(t, err)
+	assert.Equal(t, New(math.MaxInt64-1, "BRL"), total)
+
+	_, err = New(149999, "BRL").Mul(math.MaxInt64 / 1000)
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = New(math.MinInt64, "BRL").Mul(-1)
+	assert.ErrorIs(t, err, ErrAmountOverflow)
+	product, err := New(math.MaxInt64/2, "BRL").Mul(2)
+	assert.NoError(t, err)
+	assert.Equal(t, New(math.MaxInt64-1, "BRL"), product)
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(149999, "BRL"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1499.99","currency":"BRL"}`, string(data))
+
+	var m Money
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount":"0.10","currency":"USD"}`), &m))
+	assert.Equal(t, New(10, "USD"), m)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"0.101","currency":"USD"}`), &m))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"1","currency":"ZZZ"}`), &m))
+}
@@ -0,0 +1,60 @@
+// Package thumbnail reduz imagens usando só a biblioteca padrão.
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Fit reduz src para caber num quadrado de maxSide pixels, mantendo a proporção.
+// Cada pixel do resultado é a média dos pixels de src que ele cobre, o que evita o
+// serrilhado de simplesmente pular pixels. Imagens que já cabem são apenas copiadas.
+func Fit(src image.Image, maxSide int) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			dstWidth, dstHeight = maxSide, max(1, height*maxSide/width)
+		} else {
+			dstWidth, dstHeight = max(1, width*maxSide/height), maxSide
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/dstHeight)
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/dstWidth)
+			dst.SetNRGBA(x, y, average(src, x0, y0, x1, y1))
+		}
+	}
+	return dst
+}
+
+// average calcula a cor média do retângulo [x0,x1) x [y0,y1), ponderando pela transparência
+func average(src image.Image, x0, y0, x1, y1 int) color.NRGBA {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			// RGBA retorna valores pré-multiplicados de 16 bits
+			pr, pg, pb, pa := src.At(x, y).RGBA()
+			r += uint64(pr)
+			g += uint64(pg)
+			b += uint64(pb)
+			a += uint64(pa)
+			n++
+		}
+	}
+	if a == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(r * 0xff / a),
+		G: uint8(g * 0xff / a),
+		B: uint8(b * 0xff / a),
+		A: uint8(a / n >> 8),
+	}
+}
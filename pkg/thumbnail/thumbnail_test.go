@@ -0,0 +1,42 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitKeepsAspectRatio(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	assert.Equal(t, image.Rect(0, 0, 200, 100), Fit(src, 200).Bounds())
+
+	src = image.NewRGBA(image.Rect(0, 0, 300, 900))
+	assert.Equal(t, image.Rect(0, 0, 66, 200), Fit(src, 200).Bounds())
+
+	// Imagens menores não são ampliadas
+	src = image.NewRGBA(image.Rect(10, 10, 60, 40))
+	assert.Equal(t, image.Rect(0, 0, 50, 30), Fit(src, 200).Bounds())
+}
+
+func TestFitAveragesPixels(t *testing.T) {
+	// Listras verticais pretas e brancas de 1 pixel viram cinza
+	src := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	dst := Fit(src, 2)
+	assert.Equal(t, color.NRGBA{R: 127, G: 127, B: 127, A: 255}, dst.NRGBAAt(0, 0))
+
+	// Pixels transparentes não escurecem a média
+	transparent := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	transparent.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+	dst = Fit(transparent, 1)
+	assert.Equal(t, uint8(255), dst.NRGBAAt(0, 0).R)
+	assert.Equal(t, uint8(127), dst.NRGBAAt(0, 0).A)
+}
@@ -0,0 +1,10 @@
+module apis/tools/embeddedpostgres
+
+go 1.22.5
+
+require github.com/fergusstrange/embedded-postgres v1.25.0
+
+require (
+	github.com/lib/pq v1.10.4 // indirect
+	github.com/xi2/xz v0.0.0-20171230120015-48954b6210f8 // indirect
+)